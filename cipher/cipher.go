@@ -0,0 +1,251 @@
+// Package cipher provides classical ciphers like ROT13, with readers and writers that encrypt or decrypt streams,
+// like "rot13Reader" in the methods chapter.
+//
+// The ciphers work on bytes. Letters keep their case, and all other bytes (digits, spaces, UTF-8 encoded characters etc.) stay unchanged,
+// except for ROT47, which rotates all printable ASCII characters.
+// Note: These ciphers are easy to break and only meant for fun, use crypto/cipher for real encryption.
+package cipher
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrInvalidKey is returned for keys that can't be used for encryption.
+var ErrInvalidKey = errors.New("cipher: invalid key")
+
+// Cipher is a classical cipher that encrypts and decrypts byte by byte.
+type Cipher interface {
+	// Encrypter returns a function that encrypts the bytes of one message, in order.
+	// The function can have state, like the position in the key of Vigenère, so a new one is needed for each message.
+	Encrypter() func(byte) byte
+	// Decrypter returns a function that decrypts the bytes of one message, in order.
+	Decrypter() func(byte) byte
+}
+
+// funcs is a Cipher that's implemented by functions
+type funcs struct {
+	enc, dec func() func(byte) byte
+}
+
+func (c funcs) Encrypter() func(byte) byte {
+	return c.enc()
+}
+
+func (c funcs) Decrypter() func(byte) byte {
+	return c.dec()
+}
+
+// stateless returns a Cipher with functions that don't have state
+func stateless(enc, dec func(byte) byte) Cipher {
+	return funcs{
+		enc: func() func(byte) byte { return enc },
+		dec: func() func(byte) byte { return dec },
+	}
+}
+
+// ========
+
+// Caesar returns the Caesar cipher, which shifts letters by shift positions in the alphabet.
+// The shift can be negative and larger than 26.
+func Caesar(shift int) Cipher {
+	return stateless(
+		func(b byte) byte { return mapLetter(b, func(x int) int { return x + shift }) },
+		func(b byte) byte { return mapLetter(b, func(x int) int { return x - shift }) },
+	)
+}
+
+// ROT13 is the Caesar cipher with shift 13, so encryption and decryption are the same.
+var ROT13 = Caesar(13)
+
+// ROT47 rotates the 94 printable ASCII characters from "!" to "~" by 47 positions.
+// Like ROT13, encryption and decryption are the same.
+var ROT47 = stateless(rot47, rot47)
+
+func rot47(b byte) byte {
+	if b < '!' || b > '~' {
+		return b
+	}
+	return '!' + (b-'!'+47)%94
+}
+
+// Atbash reverses the alphabet, so "a" becomes "z", "b" becomes "y" and so on.
+// Encryption and decryption are the same.
+var Atbash = stateless(atbash, atbash)
+
+func atbash(b byte) byte {
+	return mapLetter(b, func(x int) int { return 25 - x })
+}
+
+// Affine returns the affine cipher, which maps the letter with the index x in the alphabet to a*x + b.
+// a must be coprime to 26 (odd and not 13), otherwise multiple letters would be mapped to the same one and it couldn't be decrypted.
+func Affine(a, b int) (Cipher, error) {
+	aInv, ok := inverse(a)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+	return stateless(
+		func(c byte) byte { return mapLetter(c, func(x int) int { return a*x + b }) },
+		func(c byte) byte { return mapLetter(c, func(x int) int { return aInv * (x - b) }) },
+	), nil
+}
+
+// inverse returns the multiplicative inverse of a modulo 26
+func inverse(a int) (int, bool) {
+	a = mod(a)
+	for i := 1; i < 26; i++ {
+		if a*i%26 == 1 {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Vigenere returns the Vigenère cipher, which shifts each letter by the next letter of the key ("a" = 0, "b" = 1 etc.).
+// Only letters use up the key, so the result doesn't depend on spaces and punctuation.
+// The key must only consist of letters, and it's case insensitive.
+func Vigenere(key string) (Cipher, error) {
+	shifts, err := KeyShifts(key)
+	if err != nil {
+		return nil, err
+	}
+	newFunc := func(sign int) func() func(byte) byte {
+		return func() func(byte) byte {
+			i := 0
+			return func(b byte) byte {
+				if !isLetter(b) {
+					return b
+				}
+				shift := shifts[i%len(shifts)]
+				i++
+				return mapLetter(b, func(x int) int { return x + sign*shift })
+			}
+		}
+	}
+	return funcs{enc: newFunc(1), dec: newFunc(-1)}, nil
+}
+
+// KeyShifts returns the shifts of the letters of a Vigenère key, like [10 4 24] for "key".
+func KeyShifts(key string) ([]int, error) {
+	if key == "" {
+		return nil, ErrInvalidKey
+	}
+	shifts := make([]int, len(key))
+	for i := 0; i < len(key); i++ {
+		x, ok := letterIndex(key[i])
+		if !ok {
+			return nil, ErrInvalidKey
+		}
+		shifts[i] = x
+	}
+	return shifts, nil
+}
+
+// ========
+
+func isLetter(b byte) bool {
+	_, ok := letterIndex(b)
+	return ok
+}
+
+// letterIndex returns the index of the letter in the alphabet, between 0 and 25, ignoring the case
+func letterIndex(b byte) (int, bool) {
+	switch {
+	case 'a' <= b && b <= 'z':
+		return int(b - 'a'), true
+	case 'A' <= b && b <= 'Z':
+		return int(b - 'A'), true
+	}
+	return 0, false
+}
+
+// mapLetter maps letters with f, which gets and returns the index in the alphabet (modulo 26), and keeps their case.
+// Other bytes are returned unchanged.
+func mapLetter(b byte, f func(int) int) byte {
+	switch {
+	case 'a' <= b && b <= 'z':
+		return 'a' + byte(mod(f(int(b-'a'))))
+	case 'A' <= b && b <= 'Z':
+		return 'A' + byte(mod(f(int(b-'A'))))
+	}
+	return b
+}
+
+// mod is like x % 26, but always positive
+func mod(x int) int {
+	return (x%26 + 26) % 26
+}
+
+// ========
+
+// Encrypt returns the encrypted string.
+func Encrypt(c Cipher, s string) string {
+	return transform(c.Encrypter(), s)
+}
+
+// Decrypt returns the decrypted string.
+func Decrypt(c Cipher, s string) string {
+	return transform(c.Decrypter(), s)
+}
+
+func transform(f func(byte) byte, s string) string {
+	b := []byte(s)
+	for i := range b {
+		b[i] = f(b[i])
+	}
+	return string(b)
+}
+
+// reader transforms the bytes read from r
+type reader struct {
+	r io.Reader
+	f func(byte) byte
+}
+
+// NewEncryptReader returns a reader that encrypts the bytes read from r.
+func NewEncryptReader(r io.Reader, c Cipher) io.Reader {
+	return &reader{r, c.Encrypter()}
+}
+
+// NewDecryptReader returns a reader that decrypts the bytes read from r.
+func NewDecryptReader(r io.Reader, c Cipher) io.Reader {
+	return &reader{r, c.Decrypter()}
+}
+
+func (r *reader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	// Exactly the n bytes that were read go through f, even together with an error,
+	// so stateful ciphers like Vigenère stay in step with the stream
+	for i := 0; i < n; i++ {
+		b[i] = r.f(b[i])
+	}
+	return n, err
+}
+
+// writer transforms the bytes before writing them to w
+type writer struct {
+	w   io.Writer
+	f   func(byte) byte
+	buf []byte
+}
+
+// NewEncryptWriter returns a writer that encrypts the bytes before writing them to w.
+// Writing can't be continued after an error, because the state of the cipher would be out of sync.
+func NewEncryptWriter(w io.Writer, c Cipher) io.Writer {
+	return &writer{w: w, f: c.Encrypter()}
+}
+
+// NewDecryptWriter returns a writer that decrypts the bytes before writing them to w.
+// Writing can't be continued after an error, because the state of the cipher would be out of sync.
+func NewDecryptWriter(w io.Writer, c Cipher) io.Writer {
+	return &writer{w: w, f: c.Decrypter()}
+}
+
+func (w *writer) Write(b []byte) (int, error) {
+	// The caller's slice must not be modified, so the result goes to a separate buffer
+	w.buf = append(w.buf[:0], b...)
+	for i := range w.buf {
+		w.buf[i] = w.f(w.buf[i])
+	}
+	return w.w.Write(w.buf)
+}
@@ -0,0 +1,216 @@
+package cipher
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func mustCipher(c Cipher, err error) Cipher {
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+var vigenere = mustCipher(Vigenere("LEMON"))
+
+var vectors = []struct {
+	name        string
+	c           Cipher
+	plain, want string
+}{
+	{"Vigenère", vigenere, "Attack at dawn!", "Lxfopv ef rnhr!"},
+	{"Vigenère lower case key", mustCipher(Vigenere("lemon")), "ATTACKATDAWN", "LXFOPVEFRNHR"},
+	{"ROT13", ROT13, "Hello, Gopher!", "Uryyb, Tbcure!"},
+	{"ROT47", ROT47, "Hello, Reader!", "w6==@[ #6256CP"},
+	{"Affine", mustCipher(Affine(5, 8)), "AFFINE cipher", "IHHWVC swfrcp"},
+	{"Caesar", Caesar(3), "Veni, vidi, vici", "Yhql, ylgl, ylfl"},
+	{"Caesar negative", Caesar(-3), "abc XYZ", "xyz UVW"},
+	{"Caesar large", Caesar(26*4 + 1), "Zz", "Aa"},
+	{"Atbash", Atbash, "Wizard of Oz", "Draziw lu La"},
+	{"UTF-8", ROT13, "Grüße, 世界", "Teüßr, 世界"},
+}
+
+func TestVectors(t *testing.T) {
+	for _, test := range vectors {
+		if got := Encrypt(test.c, test.plain); got != test.want {
+			t.Errorf("%v: Encrypt(%q) = %q, want %q", test.name, test.plain, got, test.want)
+		}
+		if got := Decrypt(test.c, test.want); got != test.plain {
+			t.Errorf("%v: Decrypt(%q) = %q, want %q", test.name, test.want, got, test.plain)
+		}
+	}
+	// Encryption and decryption are the same for these
+	for _, c := range []Cipher{ROT13, ROT47, Atbash} {
+		s := "The quick brown fox jumps over the lazy dog. 0123456789 ~!"
+		if Encrypt(c, Encrypt(c, s)) != s {
+			t.Errorf("encrypting twice doesn't give %q", s)
+		}
+	}
+}
+
+// All letters keep their case, and all other bytes stay unchanged, except for ROT47
+func TestCasePreservation(t *testing.T) {
+	all := make([]byte, 256)
+	for i := range all {
+		all[i] = byte(i)
+	}
+	for _, test := range vectors {
+		if test.name == "ROT47" {
+			continue
+		}
+		enc := []byte(Encrypt(test.c, string(all)))
+		for i, b := range enc {
+			orig := byte(i)
+			switch {
+			case 'a' <= orig && orig <= 'z':
+				if b < 'a' || b > 'z' {
+					t.Errorf("%v: %q encrypted to %q", test.name, orig, b)
+				}
+			case 'A' <= orig && orig <= 'Z':
+				if b < 'A' || b > 'Z' {
+					t.Errorf("%v: %q encrypted to %q", test.name, orig, b)
+				}
+			case b != orig:
+				t.Errorf("%v: %q encrypted to %q", test.name, orig, b)
+			}
+		}
+	}
+	// ROT47 only keeps bytes outside of "!" to "~"
+	enc := Encrypt(ROT47, string(all))
+	for i := range all {
+		if changed := enc[i] != all[i]; changed != ('!' <= all[i] && all[i] <= '~') {
+			t.Errorf("ROT47: %q encrypted to %q", all[i], enc[i])
+		}
+	}
+}
+
+func TestInvalidKeys(t *testing.T) {
+	for _, a := range []int{0, 2, 13, 26, -2, 39} {
+		if _, err := Affine(a, 1); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Affine(%v, 1) = %v, want %v", a, err, ErrInvalidKey)
+		}
+	}
+	// Negative and large values of a work if they're coprime to 26
+	for _, a := range []int{1, 3, 25, -1, 27} {
+		c, err := Affine(a, -5)
+		if err != nil {
+			t.Errorf("Affine(%v, -5) = %v", a, err)
+			continue
+		}
+		if s := "Hello World"; Decrypt(c, Encrypt(c, s)) != s {
+			t.Errorf("Affine(%v, -5) doesn't decrypt", a)
+		}
+	}
+	for _, key := range []string{"", "key word", "k3y", "schlüssel"} {
+		if _, err := Vigenere(key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Vigenere(%q) = %v, want %v", key, err, ErrInvalidKey)
+		}
+	}
+	if shifts, err := KeyShifts("keyZ"); err != nil || !slices.Equal(shifts, []int{10, 4, 24, 25}) {
+		t.Errorf("KeyShifts(%q) = %v, %v", "keyZ", shifts, err)
+	}
+}
+
+// Readers that return fewer bytes than requested must not change the result of stateful ciphers
+func TestReader(t *testing.T) {
+	plain := strings.Repeat("Attack at dawn! ", 20)
+	cipherText := Encrypt(vigenere, plain)
+	wrappers := []struct {
+		name string
+		wrap func(io.Reader) io.Reader
+	}{
+		{"plain", func(r io.Reader) io.Reader { return r }},
+		{"OneByteReader", iotest.OneByteReader},
+		{"HalfReader", iotest.HalfReader},
+		{"DataErrReader", iotest.DataErrReader},
+	}
+	for _, w := range wrappers {
+		got, err := io.ReadAll(NewDecryptReader(w.wrap(strings.NewReader(cipherText)), vigenere))
+		if err != nil || string(got) != plain {
+			t.Errorf("%v: read %q, %v, want %q", w.name, got, err, plain)
+		}
+		got, err = io.ReadAll(NewEncryptReader(w.wrap(strings.NewReader(plain)), vigenere))
+		if err != nil || string(got) != cipherText {
+			t.Errorf("%v: read %q, %v, want %q", w.name, got, err, cipherText)
+		}
+	}
+	// Each reader has its own position in the key
+	r1 := NewDecryptReader(strings.NewReader(cipherText), vigenere)
+	r2 := NewDecryptReader(strings.NewReader(cipherText), vigenere)
+	buf := make([]byte, 3)
+	io.ReadFull(r1, buf)
+	io.ReadFull(r2, buf)
+	if string(buf) != plain[:3] {
+		t.Errorf("second reader read %q, want %q", buf, plain[:3])
+	}
+
+	if err := iotest.TestReader(NewDecryptReader(strings.NewReader(cipherText), vigenere), []byte(plain)); err != nil {
+		t.Error(err)
+	}
+	errRead := errors.New("read failed")
+	if _, err := io.ReadAll(NewDecryptReader(iotest.ErrReader(errRead), vigenere)); !errors.Is(err, errRead) {
+		t.Errorf("ReadAll() = %v, want %v", err, errRead)
+	}
+}
+
+// Splitting the input into multiple writes doesn't change the result
+func TestWriter(t *testing.T) {
+	plain := "Attack at dawn! Attack at dawn!"
+	cipherText := Encrypt(vigenere, plain)
+	for split := 0; split <= len(plain); split++ {
+		var buf bytes.Buffer
+		w := NewEncryptWriter(&buf, vigenere)
+		for _, part := range []string{plain[:split], plain[split:], ""} {
+			b := []byte(part)
+			if n, err := w.Write(b); n != len(b) || err != nil {
+				t.Fatalf("Write() = %v, %v", n, err)
+			}
+			// The caller's slice isn't modified
+			if string(b) != part {
+				t.Fatalf("Write() modified its input to %q", b)
+			}
+		}
+		if buf.String() != cipherText {
+			t.Errorf("split at %v: wrote %q, want %q", split, buf.String(), cipherText)
+		}
+
+		buf.Reset()
+		w = NewDecryptWriter(&buf, vigenere)
+		io.WriteString(w, cipherText[:split])
+		io.WriteString(w, cipherText[split:])
+		if buf.String() != plain {
+			t.Errorf("split at %v: wrote %q, want %q", split, buf.String(), plain)
+		}
+	}
+
+	// One byte at a time, through a reader and a writer
+	var buf bytes.Buffer
+	w := NewDecryptWriter(&buf, vigenere)
+	if _, err := io.CopyBuffer(w, iotest.OneByteReader(NewEncryptReader(strings.NewReader(plain), vigenere)), make([]byte, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != plain {
+		t.Errorf("round trip = %q, want %q", buf.String(), plain)
+	}
+
+	// Errors of the underlying writer are returned
+	errWrite := errors.New("write failed")
+	w = NewEncryptWriter(failingWriter{errWrite}, vigenere)
+	if _, err := w.Write([]byte(plain)); !errors.Is(err, errWrite) {
+		t.Errorf("Write() = %v, want %v", err, errWrite)
+	}
+}
+
+type failingWriter struct {
+	err error
+}
+
+func (w failingWriter) Write(b []byte) (int, error) {
+	return 0, w.err
+}
@@ -0,0 +1,249 @@
+// Package clock abstracts time.Now, time.Sleep, timers and tickers,
+// so code that waits for time to pass can be tested without actually waiting.
+// Use Real in production code and a Fake in tests.
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock provides the current time and timers.
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	Sleep(d time.Duration)
+	After(d time.Duration) <-chan time.Time
+	NewTimer(d time.Duration) Timer
+	Tick(d time.Duration) <-chan time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is like *time.Timer, but the channel is returned by a method so it can be implemented by a Fake.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Ticker is like *time.Ticker, but the channel is returned by a method so it can be implemented by a Fake.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+	Reset(d time.Duration)
+}
+
+// ========
+
+// Real is the Clock of the time package.
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) Since(t time.Time) time.Duration        { return time.Since(t) }
+func (realClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) NewTimer(d time.Duration) Timer         { return realTimer{time.NewTimer(d)} }
+func (realClock) Tick(d time.Duration) <-chan time.Time  { return time.Tick(d) }
+func (realClock) NewTicker(d time.Duration) Ticker       { return realTicker{time.NewTicker(d)} }
+
+type realTimer struct {
+	t *time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time        { return t.t.C }
+func (t realTimer) Stop() bool                 { return t.t.Stop() }
+func (t realTimer) Reset(d time.Duration) bool { return t.t.Reset(d) }
+
+type realTicker struct {
+	t *time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time   { return t.t.C }
+func (t realTicker) Stop()                 { t.t.Stop() }
+func (t realTicker) Reset(d time.Duration) { t.t.Reset(d) }
+
+// ========
+
+// Fake is a Clock whose time only moves when Advance is called.
+// Timers and tickers fire in the order of their deadlines, with Now returning the deadline of the firing timer.
+// Like real tickers, fake tickers drop ticks when the receiver doesn't keep up.
+type Fake struct {
+	mux     sync.Mutex
+	cond    *sync.Cond // Signalled when timers are added
+	now     time.Time
+	waiters []*fakeTimer // Active timers, sorted by deadline
+}
+
+// NewFake creates a Fake that starts at the given time.
+func NewFake(now time.Time) *Fake {
+	f := &Fake{now: now}
+	f.cond = sync.NewCond(&f.mux)
+	return f
+}
+
+// Now returns the fake time.
+func (f *Fake) Now() time.Time {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	return f.now
+}
+
+// Since returns the fake time that passed since t.
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+// Sleep blocks until the fake time was advanced by d.
+func (f *Fake) Sleep(d time.Duration) {
+	<-f.After(d)
+}
+
+// After returns a channel that receives the fake time once it was advanced by d.
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.NewTimer(d).C()
+}
+
+// NewTimer creates a Timer that fires once the fake time was advanced by d.
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	t := &fakeTimer{
+		f: f,
+		c: make(chan time.Time, 1),
+	}
+	t.Reset(d)
+	return t
+}
+
+// Tick is like time.Tick, but with fake time.
+func (f *Fake) Tick(d time.Duration) <-chan time.Time {
+	return f.NewTicker(d).C()
+}
+
+// NewTicker creates a Ticker that fires every time the fake time was advanced by d.
+// It panics if d isn't positive, like time.NewTicker.
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	t := &fakeTimer{
+		f: f,
+		c: make(chan time.Time, 1),
+	}
+	ticker := fakeTicker{t}
+	ticker.Reset(d)
+	return ticker
+}
+
+// Advance moves the fake time forward by d and fires all timers and tickers whose deadline is reached on the way, in order.
+func (f *Fake) Advance(d time.Duration) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	end := f.now.Add(d)
+	for len(f.waiters) > 0 && !f.waiters[0].when.After(end) {
+		t := f.waiters[0]
+		f.waiters = f.waiters[1:]
+		f.now = t.when
+		t.fire()
+		if t.period > 0 {
+			t.when = t.when.Add(t.period)
+			f.add(t)
+		}
+	}
+	f.now = end
+}
+
+// BlockUntil blocks until at least n timers and tickers are waiting.
+// Tests use it to make sure a goroutine started waiting before they call Advance.
+func (f *Fake) BlockUntil(n int) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	for len(f.waiters) < n {
+		f.cond.Wait()
+	}
+}
+
+// add inserts t by deadline, after timers with the same deadline. f.mux must be held.
+func (f *Fake) add(t *fakeTimer) {
+	i := sort.Search(len(f.waiters), func(i int) bool {
+		return f.waiters[i].when.After(t.when)
+	})
+	f.waiters = append(f.waiters, nil)
+	copy(f.waiters[i+1:], f.waiters[i:])
+	f.waiters[i] = t
+	f.cond.Broadcast()
+}
+
+// remove removes t and reports whether it was waiting. f.mux must be held.
+func (f *Fake) remove(t *fakeTimer) bool {
+	for i, w := range f.waiters {
+		if w == t {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+type fakeTimer struct {
+	f      *Fake
+	c      chan time.Time
+	when   time.Time
+	period time.Duration // Only set for tickers
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.f.mux.Lock()
+	defer t.f.mux.Unlock()
+	return t.f.remove(t)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.f.mux.Lock()
+	defer t.f.mux.Unlock()
+	active := t.f.remove(t)
+	t.when = t.f.now.Add(d)
+	if d <= 0 {
+		t.fire()
+		return active
+	}
+	t.f.add(t)
+	return active
+}
+
+// fire sends the current time without blocking, like a time.Timer. t.f.mux must be held.
+func (t *fakeTimer) fire() {
+	select {
+	case t.c <- t.f.now:
+	default:
+	}
+}
+
+type fakeTicker struct {
+	t *fakeTimer
+}
+
+func (t fakeTicker) C() <-chan time.Time {
+	return t.t.c
+}
+
+func (t fakeTicker) Stop() {
+	t.t.f.mux.Lock()
+	defer t.t.f.mux.Unlock()
+	t.t.f.remove(t.t)
+}
+
+func (t fakeTicker) Reset(d time.Duration) {
+	f := t.t.f
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	f.remove(t.t)
+	t.t.period = d
+	t.t.when = f.now.Add(d)
+	f.add(t.t)
+}
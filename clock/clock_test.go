@@ -0,0 +1,136 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+var start = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// received returns the value of c, or the zero time if there's none
+func received(c <-chan time.Time) time.Time {
+	select {
+	case t := <-c:
+		return t
+	default:
+		return time.Time{}
+	}
+}
+
+func TestFakeAdvance(t *testing.T) {
+	f := NewFake(start)
+	var timers []Timer
+	for _, d := range []time.Duration{3, 1, 2, 1, 4} {
+		timers = append(timers, f.NewTimer(d*time.Second))
+	}
+	ticker := f.NewTicker(1500 * time.Millisecond)
+	f.Advance(3 * time.Second)
+	if !f.Now().Equal(start.Add(3 * time.Second)) {
+		t.Errorf("Now() = %v after Advance", f.Now())
+	}
+	// Each timer fired at its deadline, except the one after the end
+	for i, d := range []time.Duration{3, 1, 2, 1, 0} {
+		want := start.Add(d * time.Second)
+		if d == 0 {
+			want = time.Time{}
+		}
+		if got := received(timers[i].C()); !got.Equal(want) {
+			t.Errorf("timer %v fired at %v, want %v", i, got, want)
+		}
+	}
+	// The ticker fired at 1.5s and 3s, but the second tick was dropped, because nobody received the first one
+	if got := received(ticker.C()); !got.Equal(start.Add(1500 * time.Millisecond)) {
+		t.Errorf("ticker fired at %v, want the first tick", got)
+	}
+	if got := received(ticker.C()); !got.IsZero() {
+		t.Errorf("ticker fired again at %v, want dropped tick", got)
+	}
+}
+
+// Timers fire in the order of their deadlines, and Now returns the deadline while they fire
+func TestFakeNowWhileFiring(t *testing.T) {
+	f := NewFake(start)
+	c := make(chan time.Time, 10)
+	for _, d := range []time.Duration{3, 1, 2} {
+		timer := f.NewTimer(d * time.Second)
+		go func() {
+			<-timer.C()
+			c <- f.Now()
+		}()
+	}
+	for i := 1; i <= 3; i++ {
+		f.Advance(time.Second)
+		if got := <-c; !got.Equal(start.Add(time.Duration(i) * time.Second)) {
+			t.Errorf("timer %v: Now() = %v", i, got)
+		}
+	}
+}
+
+func TestFakeStopReset(t *testing.T) {
+	f := NewFake(start)
+	timer := f.NewTimer(time.Second)
+	if !timer.Stop() {
+		t.Error("Stop() of an active timer = false")
+	}
+	if timer.Stop() {
+		t.Error("second Stop() = true")
+	}
+	f.Advance(time.Minute)
+	if got := received(timer.C()); !got.IsZero() {
+		t.Error("stopped timer fired")
+	}
+	if timer.Reset(time.Second) {
+		t.Error("Reset() of a stopped timer = true")
+	}
+	f.Advance(time.Second)
+	if got := received(timer.C()); !got.Equal(start.Add(time.Minute + time.Second)) {
+		t.Errorf("reset timer fired at %v", got)
+	}
+	// A non-positive duration fires right away, like with time.Timer
+	timer.Reset(0)
+	if got := received(timer.C()); !got.Equal(f.Now()) {
+		t.Errorf("timer with 0 fired at %v, want now", got)
+	}
+
+	ticker := f.NewTicker(time.Second)
+	ticker.Stop()
+	f.Advance(time.Minute)
+	if got := received(ticker.C()); !got.IsZero() {
+		t.Error("stopped ticker fired")
+	}
+	ticker.Reset(2 * time.Second)
+	f.Advance(2 * time.Second)
+	if got := received(ticker.C()); !got.Equal(f.Now()) {
+		t.Errorf("reset ticker fired at %v", got)
+	}
+}
+
+func TestFakeSleep(t *testing.T) {
+	f := NewFake(start)
+	done := make(chan struct{})
+	go func() {
+		f.Sleep(time.Second)
+		close(done)
+	}()
+	f.BlockUntil(1)
+	f.Advance(999 * time.Millisecond)
+	select {
+	case <-done:
+		t.Fatal("Sleep returned too early")
+	default:
+	}
+	f.Advance(time.Millisecond)
+	<-done
+	if d := f.Since(start); d != time.Second {
+		t.Errorf("Since() = %v, want 1s", d)
+	}
+}
+
+func TestFakeTickerPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("NewTicker(0) didn't panic")
+		}
+	}()
+	NewFake(start).NewTicker(0)
+}
@@ -0,0 +1,81 @@
+// Command crack finds the key of Caesar or Vigenère ciphertext with frequency analysis and prints the most likely plaintexts.
+//
+// Usage:
+//
+//	crack [-vigenere] [-max-key 20] [-n 3] [input ...]
+//
+// Without input files it reads stdin, for example:
+//
+//	echo "Lbh penpxrq gur pbqr!" | crack
+//	transform -t vigenere:lemon book.txt | crack -vigenere
+//
+// Vigenère needs much more text than Caesar, because each letter of the key is found separately.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/philippgille/hello-go/crack"
+)
+
+// run gets the arguments without the program name, and reads stdin if no files are given
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("crack", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	vigenere := flags.Bool("vigenere", false, "crack Vigenère instead of Caesar")
+	maxKeyLen := flags.Int("max-key", 20, "maximum Vigenère key `length`")
+	n := flags.Int("n", 3, "`number` of candidates to print")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *maxKeyLen < 1 || *n < 1 {
+		flags.Usage()
+		return errors.New("-max-key and -n must be at least 1")
+	}
+
+	var inputs []io.Reader
+	for _, name := range flags.Args() {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		inputs = append(inputs, f)
+	}
+	if len(inputs) == 0 {
+		inputs = append(inputs, stdin)
+	}
+	// Frequency analysis needs the whole text
+	ciphertext, err := io.ReadAll(io.MultiReader(inputs...))
+	if err != nil {
+		return err
+	}
+
+	var candidates []crack.Candidate
+	if *vigenere {
+		candidates = crack.Vigenere(string(ciphertext), *maxKeyLen)
+	} else {
+		candidates = crack.Caesar(string(ciphertext))
+	}
+	for i, c := range candidates {
+		if i == *n {
+			break
+		}
+		fmt.Fprintf(stdout, "#%v key %q (chi-squared %.1f):\n%s\n", i+1, c.Key, c.Score, c.Plaintext)
+	}
+	return nil
+}
+
+func main() {
+	switch err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); {
+	case errors.Is(err, flag.ErrHelp):
+		// The usage was printed for -h
+	case err != nil:
+		fmt.Fprintln(os.Stderr, "crack:", err)
+		os.Exit(1)
+	}
+}
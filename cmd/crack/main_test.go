@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/philippgille/hello-go/cipher"
+)
+
+func TestRunCaesar(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-n", "1"}, strings.NewReader("Lbh penpxrq gur pbqr!"), &stdout, &stderr); err != nil {
+		t.Fatal(err)
+	}
+	if want := "#1 key \"n\" "; !strings.HasPrefix(stdout.String(), want) {
+		t.Errorf("output %q doesn't start with %q", stdout.String(), want)
+	}
+	if !strings.Contains(stdout.String(), "You cracked the code!") || strings.Contains(stdout.String(), "#2") {
+		t.Errorf("output %q, want only the plaintext of the best key", stdout.String())
+	}
+}
+
+func TestRunVigenere(t *testing.T) {
+	const plaintext = `Go is an open source programming language that makes it simple to build secure, scalable systems.
+Its concurrency mechanisms make it easy to write programs that get the most out of multicore and networked machines,
+while its type system enables flexible and modular program construction. Go compiles quickly to machine code,
+yet has the convenience of garbage collection and the power of run-time reflection.`
+	c, _ := cipher.Vigenere("gopher")
+	// Two files are read like one text
+	dir := t.TempDir()
+	ciphertext := cipher.Encrypt(c, plaintext)
+	half := len(ciphertext) / 2
+	var names []string
+	for i, part := range []string{ciphertext[:half], ciphertext[half:]} {
+		name := filepath.Join(dir, string(rune('a'+i)))
+		if err := os.WriteFile(name, []byte(part), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := run(append([]string{"-vigenere", "-max-key", "10"}, names...), strings.NewReader(""), &stdout, &stderr); err != nil {
+		t.Fatal(err)
+	}
+	if want := "#1 key \"gopher\" "; !strings.HasPrefix(stdout.String(), want) {
+		t.Errorf("output starts with %q, want %q", strings.SplitN(stdout.String(), "\n", 2)[0], want)
+	}
+	if !strings.Contains(stdout.String(), plaintext) {
+		t.Error("output doesn't contain the plaintext")
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantUsage bool
+	}{
+		{"negative max-key", []string{"-vigenere", "-max-key", "-1"}, true},
+		{"zero max-key", []string{"-max-key", "0"}, true},
+		{"zero n", []string{"-n", "0"}, true},
+		{"unknown flag", []string{"-x"}, true},
+		{"invalid number", []string{"-n", "three"}, true},
+		{"missing file", []string{filepath.Join(t.TempDir(), "missing")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			err := run(tt.args, strings.NewReader("Lbh penpxrq gur pbqr!"), &stdout, &stderr)
+			if err == nil {
+				t.Fatal("no error")
+			}
+			if stdout.Len() != 0 {
+				t.Errorf("output %q despite the error", stdout.String())
+			}
+			if usage := strings.Contains(stderr.String(), "Usage of crack"); usage != tt.wantUsage {
+				t.Errorf("usage printed: %v, want %v", usage, tt.wantUsage)
+			}
+		})
+	}
+
+	var stderr bytes.Buffer
+	if err := run([]string{"-h"}, strings.NewReader(""), new(bytes.Buffer), &stderr); !errors.Is(err, flag.ErrHelp) || !strings.Contains(stderr.String(), "-max-key") {
+		t.Errorf("run(-h) = %v with usage %q, want flag.ErrHelp and the usage", err, stderr.String())
+	}
+}
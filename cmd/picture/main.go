@@ -0,0 +1,71 @@
+// Command picture renders a formula like the one of "Pic" in the moretypes chapter to a PNG, JPEG or GIF file.
+//
+// Usage:
+//
+//	picture [-formula avg] [-width 256] [-height 256] [-o picture.png] [-format png]
+//
+// The format is determined by the extension of the output file, unless -format is given.
+// With "-o -" the image is written to stdout, for example:
+//
+//	picture -formula xor -o - -format gif > xor.gif
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/philippgille/hello-go/picture"
+)
+
+// run gets the arguments without the program name, and writes the image to stdout for "-o -"
+func run(args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("picture", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	formula := flags.String("formula", "avg", "pixel `formula`, one of "+strings.Join(picture.FormulaNames(), ", ")+" (avg is (x+y)/2, mul x*y, xor x^y, and x&y, mod x*y%(x+y+1))")
+	width := flags.Int("width", 256, "width in `pixels`")
+	height := flags.Int("height", 256, "height in `pixels`")
+	out := flags.String("o", "picture.png", "output `file`, or - for stdout")
+	format := flags.String("format", "", "image `format`, one of "+strings.Join(picture.Formats, ", ")+" (default: extension of the output file)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	f, ok := picture.Formulas[*formula]
+	if !ok {
+		return fmt.Errorf("unknown formula %q, must be one of %v", *formula, picture.FormulaNames())
+	}
+	if *width <= 0 || *height <= 0 {
+		return fmt.Errorf("invalid size %vx%v", *width, *height)
+	}
+	img := picture.FromFormula(f, *width, *height)
+
+	switch {
+	case *out != "-" && *format == "":
+		return picture.Save(*out, img)
+	case *out != "-":
+		// The format is given explicitly, so the extension doesn't matter
+		return picture.SaveAs(*out, img, *format)
+	case *format == "":
+		return fmt.Errorf("-format is required for stdout")
+	}
+	w := bufio.NewWriter(stdout)
+	if err := picture.Encode(w, img, *format); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+func main() {
+	switch err := run(os.Args[1:], os.Stdout, os.Stderr); {
+	case errors.Is(err, flag.ErrHelp):
+		// The usage was printed for -h
+	case err != nil:
+		fmt.Fprintln(os.Stderr, "picture:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// decodeFile returns the format and size of the image in the file
+func decodeFile(t *testing.T, path string) (string, image.Point) {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	img, format, err := image.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return format, img.Bounds().Size()
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		args       []string
+		file       string
+		wantFormat string
+		wantSize   image.Point
+	}{
+		{[]string{"-o", "a.png"}, "a.png", "png", image.Pt(256, 256)},
+		{[]string{"-formula", "xor", "-width", "40", "-height", "30", "-o", "b.jpg"}, "b.jpg", "jpeg", image.Pt(40, 30)},
+		// -format wins over the extension
+		{[]string{"-formula", "mod", "-width", "8", "-height", "8", "-format", "gif", "-o", "c.png"}, "c.png", "gif", image.Pt(8, 8)},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			args := append([]string(nil), tt.args...)
+			args[len(args)-1] = path
+			var stdout, stderr bytes.Buffer
+			if err := run(args, &stdout, &stderr); err != nil {
+				t.Fatal(err)
+			}
+			if stdout.Len() != 0 || stderr.Len() != 0 {
+				t.Errorf("output %q and %q, want none", stdout.String(), stderr.String())
+			}
+			if format, size := decodeFile(t, path); format != tt.wantFormat || size != tt.wantSize {
+				t.Errorf("got %v image of size %v, want %v of size %v", format, size, tt.wantFormat, tt.wantSize)
+			}
+		})
+	}
+}
+
+func TestRunStdout(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-width", "10", "-height", "5", "-o", "-", "-format", "png"}, &stdout, &stderr); err != nil {
+		t.Fatal(err)
+	}
+	img, format, err := image.Decode(&stdout)
+	if err != nil || format != "png" || img.Bounds().Size() != image.Pt(10, 5) {
+		t.Errorf("decoded stdout as %v, %v", format, err)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name      string
+		args      []string
+		wantUsage bool
+	}{
+		{"unknown flag", []string{"-x"}, true},
+		{"invalid number", []string{"-width", "wide"}, true},
+		{"unknown formula", []string{"-formula", "sin"}, false},
+		{"zero width", []string{"-width", "0"}, false},
+		{"negative height", []string{"-height", "-1"}, false},
+		{"stdout without format", []string{"-o", "-"}, false},
+		{"unknown format", []string{"-o", "-", "-format", "bmp"}, false},
+		{"unknown extension", []string{"-o", filepath.Join(dir, "a.bmp")}, false},
+		{"missing directory", []string{"-o", filepath.Join(dir, "missing", "a.png")}, false},
+		// JPEG images are at most 65535 pixels wide, so encoding fails after the file was created
+		{"too large", []string{"-width", "70000", "-height", "1", "-o", filepath.Join(dir, "a.jpg")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if err := run(tt.args, &stdout, &stderr); err == nil {
+				t.Fatal("no error")
+			}
+			if stdout.Len() != 0 {
+				t.Errorf("%v bytes written to stdout despite the error", stdout.Len())
+			}
+			if usage := strings.Contains(stderr.String(), "Usage of picture"); usage != tt.wantUsage {
+				t.Errorf("usage printed: %v, want %v", usage, tt.wantUsage)
+			}
+		})
+	}
+	// No files are left behind
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("files left after errors: %v", entries)
+	}
+
+	var stderr bytes.Buffer
+	if err := run([]string{"-h"}, new(bytes.Buffer), &stderr); !errors.Is(err, flag.ErrHelp) || !strings.Contains(stderr.String(), "-formula") {
+		t.Errorf("run(-h) = %v with usage %q, want flag.ErrHelp and the usage", err, stderr.String())
+	}
+}
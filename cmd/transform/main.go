@@ -0,0 +1,261 @@
+// Command transform encodes or decodes streams with a chain of transforms,
+// like "rot13Reader" in the methods chapter, but for any input.
+//
+// Usage:
+//
+//	transform -t rot13,base64 [-d] [-o output] [input ...]
+//
+// Without input files (or with "-") it reads stdin, and without "-o" it writes to stdout.
+// The data is streamed, so files of any size work.
+// Decoding applies the inverse transforms in reverse order, so "-d" with the same chain restores the input:
+//
+//	transform -t vigenere:lemon,gzip,base64 secret.txt > secret.b64
+//	transform -t vigenere:lemon,gzip,base64 -d secret.b64
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/philippgille/hello-go/cipher"
+)
+
+// transform can encode by wrapping a writer and decode by wrapping a reader
+type transform struct {
+	encoder func(w io.Writer) io.WriteCloser
+	decoder func(r io.Reader) (io.Reader, error)
+}
+
+// cipherTransform encrypts when encoding and decrypts when decoding
+func cipherTransform(c cipher.Cipher) transform {
+	return transform{
+		encoder: func(w io.Writer) io.WriteCloser { return nopCloser{cipher.NewEncryptWriter(w, c)} },
+		decoder: func(r io.Reader) (io.Reader, error) { return cipher.NewDecryptReader(r, c), nil },
+	}
+}
+
+var (
+	base64Transform = transform{
+		encoder: func(w io.Writer) io.WriteCloser { return base64.NewEncoder(base64.StdEncoding, w) },
+		// The decoder ignores newlines, like the one at the end of "echo" output
+		decoder: func(r io.Reader) (io.Reader, error) { return base64.NewDecoder(base64.StdEncoding, r), nil },
+	}
+	hexTransform = transform{
+		encoder: func(w io.Writer) io.WriteCloser { return nopCloser{hex.NewEncoder(w)} },
+		decoder: func(r io.Reader) (io.Reader, error) { return hex.NewDecoder(skipNewlines{r}), nil },
+	}
+	gzipTransform = transform{
+		encoder: func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+		decoder: func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+	}
+)
+
+// parseTransform parses a transform like "rot13", "caesar:3" or "vigenere:key"
+func parseTransform(s string) (transform, error) {
+	name, arg, hasArg := strings.Cut(s, ":")
+	if hasArg != (name == "caesar" || name == "vigenere" || name == "affine") {
+		return transform{}, fmt.Errorf("invalid transform %q", s)
+	}
+	switch name {
+	case "rot13":
+		return cipherTransform(cipher.ROT13), nil
+	case "rot47":
+		return cipherTransform(cipher.ROT47), nil
+	case "atbash":
+		return cipherTransform(cipher.Atbash), nil
+	case "caesar":
+		shift, err := strconv.Atoi(arg)
+		if err != nil {
+			return transform{}, fmt.Errorf("invalid shift in %q", s)
+		}
+		return cipherTransform(cipher.Caesar(shift)), nil
+	case "vigenere":
+		c, err := cipher.Vigenere(arg)
+		if err != nil {
+			return transform{}, fmt.Errorf("invalid key in %q: %v", s, err)
+		}
+		return cipherTransform(c), nil
+	case "affine":
+		a, b, ok := strings.Cut(arg, ",")
+		x, errA := strconv.Atoi(a)
+		y, errB := strconv.Atoi(b)
+		if !ok || errA != nil || errB != nil {
+			return transform{}, fmt.Errorf("invalid key in %q, must be like \"affine:5,8\"", s)
+		}
+		c, err := cipher.Affine(x, y)
+		if err != nil {
+			return transform{}, fmt.Errorf("invalid key in %q: %v", s, err)
+		}
+		return cipherTransform(c), nil
+	case "base64":
+		return base64Transform, nil
+	case "hex":
+		return hexTransform, nil
+	case "gzip":
+		return gzipTransform, nil
+	}
+	return transform{}, fmt.Errorf("unknown transform %q", s)
+}
+
+// parseChain parses comma-separated transforms like "vigenere:lemon,gzip,base64"
+func parseChain(spec string) ([]transform, error) {
+	// The key of affine contains a comma ("affine:5,8"), so the part after it is joined to it
+	var chain []transform
+	parts := strings.Split(spec, ",")
+	for i := 0; i < len(parts); i++ {
+		s := parts[i]
+		if strings.HasPrefix(s, "affine:") && i+1 < len(parts) {
+			i++
+			s += "," + parts[i]
+		}
+		t, err := parseTransform(s)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, t)
+	}
+	return chain, nil
+}
+
+// encode writes the input through all encoders, with the first transform being applied first
+func encode(chain []transform, out io.Writer, in io.Reader) error {
+	// The writers are wrapped from the last transform to the first one, so the first one is the outermost
+	writers := make([]io.WriteCloser, len(chain))
+	var w io.Writer = out
+	for i := len(chain) - 1; i >= 0; i-- {
+		writers[i] = chain[i].encoder(w)
+		w = writers[i]
+	}
+	if _, err := io.Copy(w, in); err != nil {
+		return err
+	}
+	// Closing flushes buffered data (like the last base64 block) to the next writer, so the outermost one must be closed first
+	for _, wc := range writers {
+		if err := wc.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decode reads the input through all decoders, with the last transform being inverted first
+func decode(chain []transform, out io.Writer, in io.Reader) error {
+	r := in
+	for i := len(chain) - 1; i >= 0; i-- {
+		var err error
+		if r, err = chain[i].decoder(r); err != nil {
+			return err
+		}
+	}
+	_, err := io.Copy(out, r)
+	return err
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+// skipNewlines removes "\r" and "\n", which the hex decoder doesn't accept
+type skipNewlines struct {
+	r io.Reader
+}
+
+func (s skipNewlines) Read(b []byte) (int, error) {
+	for {
+		n, err := s.r.Read(b)
+		kept := 0
+		for _, c := range b[:n] {
+			if c != '\r' && c != '\n' {
+				b[kept] = c
+				kept++
+			}
+		}
+		// Returning 0 bytes without error is discouraged, so read again if everything was removed
+		if kept > 0 || err != nil {
+			return kept, err
+		}
+	}
+}
+
+func run() error {
+	chainFlag := flag.String("t", "", "comma-separated `transforms`: rot13, rot47, atbash, caesar:N, vigenere:KEY, affine:A,B, base64, hex, gzip")
+	decodeFlag := flag.Bool("d", false, "decode instead of encode")
+	outFlag := flag.String("o", "", "output `file` (default stdout)")
+	flag.Parse()
+
+	if *chainFlag == "" {
+		flag.Usage()
+		return errors.New("no transforms given")
+	}
+	chain, err := parseChain(*chainFlag)
+	if err != nil {
+		return err
+	}
+
+	var inputs []io.Reader
+	for _, name := range flag.Args() {
+		if name == "-" {
+			inputs = append(inputs, os.Stdin)
+			continue
+		}
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		inputs = append(inputs, f)
+	}
+	if len(inputs) == 0 {
+		inputs = append(inputs, os.Stdin)
+	}
+	in := io.MultiReader(inputs...)
+
+	out := os.Stdout
+	if *outFlag != "" {
+		f, err := os.Create(*outFlag)
+		if err != nil {
+			return err
+		}
+		defer f.Close() // For the error cases, the error of the second Close is ignored
+		out = f
+	}
+	// Many small writes (like from the hex encoder) are slow on unbuffered files
+	bw := bufio.NewWriter(out)
+
+	if *decodeFlag {
+		err = decode(chain, bw, in)
+	} else {
+		err = encode(chain, bw, in)
+	}
+	if err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	if out != os.Stdout {
+		// The deferred Close above ignores its error, but here a failed Close means the output file may be incomplete
+		return out.Close()
+	}
+	return nil
+}
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "transform:", err)
+		os.Exit(1)
+	}
+}
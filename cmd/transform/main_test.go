@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestRoundTrip(t *testing.T) {
+	text := []byte(strings.Repeat("Attack at dawn! The quick brown fox jumps over the lazy dog.\n", 50))
+	binary := make([]byte, 10000)
+	rand.New(rand.NewSource(1)).Read(binary)
+
+	chains := []string{
+		"rot13",
+		"rot47",
+		"atbash",
+		"caesar:-3",
+		"vigenere:lemon",
+		"affine:5,8",
+		"base64",
+		"hex",
+		"gzip",
+		"vigenere:lemon,gzip,base64",
+		"affine:5,8,rot13,hex",
+		"gzip,hex,rot47,base64,gzip,base64",
+		"base64,base64,base64",
+	}
+	for _, spec := range chains {
+		chain, err := parseChain(spec)
+		if err != nil {
+			t.Fatalf("parseChain(%q) = %v", spec, err)
+		}
+		for _, in := range [][]byte{text, binary, nil} {
+			var encoded bytes.Buffer
+			// Short reads don't change the result
+			if err := encode(chain, &encoded, iotest.OneByteReader(bytes.NewReader(in))); err != nil {
+				t.Fatalf("%v: encode() = %v", spec, err)
+			}
+			var decoded bytes.Buffer
+			if err := decode(chain, &decoded, iotest.HalfReader(bytes.NewReader(encoded.Bytes()))); err != nil {
+				t.Fatalf("%v: decode() = %v", spec, err)
+			}
+			if !bytes.Equal(decoded.Bytes(), in) {
+				t.Errorf("%v: round trip of %v bytes gave %v different bytes", spec, len(in), decoded.Len())
+			}
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		spec, in, want string
+	}{
+		{"rot13", "Hello", "Uryyb"},
+		// The first transform is applied first
+		{"rot13,base64", "Hello", "VXJ5eWI="},
+		{"base64,rot13", "Hello", "FTIfoT8="},
+		{"hex", "Go", "476f"},
+		{"affine:5,8,hex", "A", "49"},
+	}
+	for _, test := range tests {
+		chain, err := parseChain(test.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		if err := encode(chain, &out, strings.NewReader(test.in)); err != nil || out.String() != test.want {
+			t.Errorf("encode(%v, %q) = %q, %v, want %q", test.spec, test.in, out.String(), err, test.want)
+		}
+	}
+}
+
+// Input from "echo" or editors ends with a newline, which is ignored by base64 and hex
+func TestDecodeNewlines(t *testing.T) {
+	for spec, in := range map[string]string{
+		"rot13,base64": "VXJ5\neWI=\n",
+		"hex":          "48656c\r\n6c6f\n",
+		"rot13,hex":    "\n\n5572797962\n",
+	} {
+		chain, _ := parseChain(spec)
+		var out bytes.Buffer
+		if err := decode(chain, &out, strings.NewReader(in)); err != nil || out.String() != "Hello" {
+			t.Errorf("decode(%v, %q) = %q, %v, want \"Hello\"", spec, in, out.String(), err)
+		}
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	for spec, in := range map[string]string{
+		"base64":      "not base64!",
+		"hex":         "xyz",
+		"gzip":        "not gzip",
+		"gzip,base64": "bm90IGd6aXA=",
+	} {
+		chain, _ := parseChain(spec)
+		if err := decode(chain, &bytes.Buffer{}, strings.NewReader(in)); err == nil {
+			t.Errorf("decode(%v, %q) = nil error", spec, in)
+		}
+	}
+}
+
+func TestParseChain(t *testing.T) {
+	if chain, err := parseChain("affine:5,8,caesar:3,affine:3,1"); err != nil || len(chain) != 3 {
+		t.Errorf("parseChain() = %v transforms, %v, want 3", len(chain), err)
+	}
+	invalid := []string{
+		"", "rot", "rot13,", "rot13:1", "caesar", "caesar:x", "vigenere:", "vigenere:k3y",
+		"affine:5", "affine:2,1", "affine:5,x", "base64:x", "gzip,,hex",
+	}
+	for _, spec := range invalid {
+		if _, err := parseChain(spec); err == nil {
+			t.Errorf("parseChain(%q) = nil error", spec)
+		}
+	}
+}
@@ -1,24 +1,54 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
+	"log"
+	"math/big"
+	"os"
 	"sync"
 	"time"
 
+	"github.com/philippgille/hello-go/clock"
+	"github.com/philippgille/hello-go/fib"
+	"github.com/philippgille/hello-go/future"
+	"github.com/philippgille/hello-go/parallel"
+	"github.com/philippgille/hello-go/pipeline"
+	"github.com/philippgille/hello-go/pubsub"
+	"github.com/philippgille/hello-go/ratelimit"
+	"github.com/philippgille/hello-go/schedule"
+	"github.com/philippgille/hello-go/semaphore"
+	"github.com/philippgille/hello-go/tracing"
+	"github.com/philippgille/hello-go/workerpool"
 	"golang.org/x/tour/tree"
 )
 
+// clk is used instead of calling time.Sleep, time.Tick and time.After directly,
+// so that tests can replace it with a clock.Fake and don't have to wait
+var clk clock.Clock = clock.Real
+
+// tracer records goroutine spans and channel operations of some examples, if enabled with the "-trace" flag.
+// Its methods don't do anything while it's nil.
+var tracer *tracing.Tracer
+
+var traceFile = flag.String("trace", "", "write a Chrome trace (for https://ui.perfetto.dev) to this file and print a timeline")
+
 // calling this function in a goroutin executes it in a lightweight thread
 func say(s string) {
 	for i := 0; i < 5; i++ {
-		time.Sleep(100 * time.Millisecond)
+		clk.Sleep(100 * time.Millisecond)
 		fmt.Println(s)
 	}
 }
 
 func myGoroutine() {
-	go say("world") // executes "say" in a separate goroutine and continues immediately
+	tracer.Go("say world", func() {
+		say("world") // executes "say" in a separate goroutine and continues immediately
+	})
+	end := tracer.Span("say hello")
 	say("hello")
+	end()
 }
 
 // ========
@@ -42,21 +72,66 @@ func myChannel() {
 	fmt.Println(x, y, x+y)
 }
 
+// With the "future" package, each half gets its own typed result, so it's clear which sum belongs to which half
+func myFuture() {
+	s := []int{7, 2, 8, -9, 4, 0}
+
+	sumOf := func(s []int) func() (int, error) {
+		return func() (int, error) {
+			c := make(chan int, 1)
+			sum(s, c)
+			return <-c, nil
+		}
+	}
+	first := future.Go(sumOf(s[:len(s)/2]))
+	second := future.Go(sumOf(s[len(s)/2:]))
+	x, _ := first.Await(context.Background())
+	y, _ := second.Await(context.Background())
+	fmt.Println(x, y, x+y)
+
+	// Or wait for both at once
+	sums, err := future.All(context.Background(), first, second)
+	fmt.Println(sums, err)
+}
+
+// The "parallel" package generalizes this to any number of workers (GOMAXPROCS by default) and any associative operation
+func myParallelSum() {
+	s := make([]float64, 10000000)
+	for i := range s {
+		s[i] = float64(i)
+	}
+
+	start := time.Now()
+	var seq float64
+	for _, v := range s {
+		seq += v
+	}
+	fmt.Println("sequential:", seq, time.Since(start))
+
+	start = time.Now()
+	par := parallel.Sum(s, 0)
+	fmt.Println("parallel:", par, time.Since(start))
+
+	// Prefix sums: [7 9 17 8 12 12]
+	fmt.Println(parallel.Scan([]int{7, 2, 8, -9, 4, 0}, 0, func(a, b int) int {
+		return a + b
+	}, 2))
+}
+
 // ========
 
 // Closing a channel
-func fibonacci(n int, c chan int) {
-	x, y := 0, 1
-	for i := 0; i < n; i++ {
+// The values come from an iterator of the "fib" package, because int overflows after the 92nd value
+func fibonacci(n int, c chan *big.Int) {
+	for x := range fib.Seq(n) {
 		c <- x // Send operation
-		x, y = y, x+y
 	}
 	close(c)
 }
 
 // Iterate over messages in a channel
 func myChannelRange() {
-	c := make(chan int, 10)
+	c := make(chan *big.Int, 10)
 	go fibonacci(cap(c), c)
 	for i := range c { // Receive operation
 		fmt.Println(i)
@@ -69,12 +144,14 @@ func myChannelRange() {
 // A select blocks until one of its cases can run, then it executes that case. It chooses one at random if multiple are ready.
 
 // Calculates fibonacci until a value is received in the "quit" channel
-func fibonacci2(c, quit chan int) {
-	x, y := 0, 1
+func fibonacci2(c chan *big.Int, quit chan int) {
+	next := fib.Closure()
+	x := next()
 	for {
 		select {
 		case c <- x: // Send operation
-			x, y = y, x+y
+			tracer.Instant("send c", "chan")
+			x = next()
 		case <-quit: // Receive operation
 			fmt.Println("quit")
 			return
@@ -84,19 +161,77 @@ func fibonacci2(c, quit chan int) {
 
 // Starts a goroutine that prints 10 values received in the "c" channel and then sends a value to the "quit" channel
 func myChannelSelect() {
-	c := make(chan int)
+	c := make(chan *big.Int)
 	quit := make(chan int)
 	go func() {
 		for i := 0; i < 10; i++ {
-			fmt.Println(<-c) // Receive operation
+			v, _ := tracing.Recv(tracer, c, "c") // Receive operation
+			fmt.Println(v)
 		}
-		quit <- 0 // Send operation
+		tracing.Send(tracer, quit, 0, "quit") // Send operation
 	}()
 	fibonacci2(c, quit)
 }
 
 // ========
 
+// The same can be done with the stages of the "pipeline" package, which take care of closing channels and cancellation.
+// Squares the values of "myChannel" with 2 workers and sums them up.
+func myPipeline() {
+	p := pipeline.New(context.Background())
+	s := pipeline.Source(p, 7, 2, 8, -9, 4, 0)
+	squares := pipeline.FanOut(p, s, 2, true, func(ctx context.Context, v int) (int, error) {
+		return v * v, nil
+	})
+	sum, err := pipeline.Reduce(p, squares, 0, func(acc, v int) int {
+		return acc + v
+	})
+	fmt.Println(sum, err)
+}
+
+// ========
+
+// The "default" case in a select statement is run if no other case is ready
+func myTickBoom() {
+	tick := clk.Tick(100 * time.Millisecond)
+	boom := clk.After(500 * time.Millisecond)
+	var br bool
+	for br == false {
+		select {
+		case <-tick:
+			fmt.Println("tick.")
+		case <-boom:
+			fmt.Println("BOOM!")
+			br = true
+		default:
+			fmt.Println("    .")
+			clk.Sleep(50 * time.Millisecond)
+		}
+	}
+}
+
+// ========
+
+// The "pubsub" package delivers messages to all channels that subscribed to a matching topic
+func myPubSub() {
+	b := pubsub.New[string](10, pubsub.DropOldest)
+	golang, _ := b.Subscribe("golang.>")
+	pkg, _ := b.Subscribe("golang.pkg.*")
+
+	b.Publish("golang.pkg.fmt", "Package fmt")
+	b.Publish("golang.cmd", "Commands")
+	b.Close() // Closes the channels, but the buffered messages can still be received
+
+	for m := range golang {
+		fmt.Println("golang:", m.Topic, m.Payload)
+	}
+	for m := range pkg {
+		fmt.Println("pkg:", m.Topic, m.Payload)
+	}
+}
+
+// ========
+
 // Goroutine exercise
 
 // Walk walks the tree t sending all values
@@ -114,16 +249,44 @@ func Walk(t *tree.Tree, ch chan int) {
 // Same determines whether the trees
 // t1 and t2 contain the same values.
 func Same(t1, t2 *tree.Tree) bool {
-	ch1 := make(chan int, 10)
-	ch2 := make(chan int, cap(ch1))
-	go Walk(t1, ch1)
-	go Walk(t2, ch2)
-	for i := 0; i < cap(ch1); i++ {
-		if <-ch1 != <-ch2 {
+	// Closing "quit" stops the walks when returning early, otherwise they would block forever on sending (and leak)
+	quit := make(chan struct{})
+	defer close(quit)
+	ch1 := make(chan int)
+	ch2 := make(chan int)
+	go walkAndClose(t1, ch1, quit)
+	go walkAndClose(t2, ch2, quit)
+	for {
+		v1, ok1 := <-ch1
+		v2, ok2 := <-ch2
+		if ok1 != ok2 || v1 != v2 {
 			return false
 		}
+		if !ok1 {
+			return true
+		}
 	}
-	return true
+}
+
+// walkAndClose is like Walk, but stops when "quit" is closed and closes ch when it's done
+func walkAndClose(t *tree.Tree, ch chan int, quit chan struct{}) {
+	defer close(ch)
+	walkUntil(t, ch, quit)
+}
+
+func walkUntil(t *tree.Tree, ch chan int, quit chan struct{}) bool {
+	if t == nil {
+		return true
+	}
+	if !walkUntil(t.Left, ch, quit) {
+		return false
+	}
+	select {
+	case ch <- t.Value:
+	case <-quit:
+		return false
+	}
+	return walkUntil(t.Right, ch, quit)
 }
 
 // ========
@@ -158,7 +321,22 @@ func myMutex() {
 		go c.Inc("somekey")
 	}
 
-	time.Sleep(time.Second)
+	clk.Sleep(time.Second)
+	fmt.Println(c.Value("somekey"))
+}
+
+// The same with a "workerpool", which runs the 1000 increments on 10 goroutines.
+// Sleeping isn't necessary, because Close waits until all tasks are done.
+func myWorkerPool() {
+	c := SafeCounter{v: make(map[string]int)}
+	pool := workerpool.New[struct{}](workerpool.Options{Workers: 10})
+	for i := 0; i < 1000; i++ {
+		pool.Submit(context.Background(), func(ctx context.Context) (struct{}, error) {
+			c.Inc("somekey")
+			return struct{}{}, nil
+		})
+	}
+	pool.Close(context.Background())
 	fmt.Println(c.Value("somekey"))
 }
 
@@ -181,7 +359,7 @@ type fakeResult struct {
 }
 
 func (f fakeFetcher) Fetch(url string) (string, []string, error) {
-	time.Sleep(time.Millisecond * 500) // Added by myself to see if the parallelization worked
+	clk.Sleep(time.Millisecond * 500) // Added by myself to see if the parallelization worked
 	if res, ok := f[url]; ok {
 		return res.body, res.urls, nil
 	}
@@ -197,15 +375,32 @@ var myCache = cache{
 	result: make(map[string]fakeResult),
 }
 
+// Limits the number of fetches that run at the same time
+var fetchSemaphore = semaphore.NewWeighted(3)
+
 // Crawl uses fetcher to recursively crawl
 // pages starting with url, to a maximum of depth.
+// It returns when all pages are crawled.
 func Crawl(url string, depth int, fetcher Fetcher) {
-	if depth <= 0 || myCache.result[url].body != "" {
+	// Limits the fetches to bursts of 2, then one every 100ms, so the crawler doesn't overload the server.
+	// It's created here and not at package initialization, so it uses the current "clk".
+	limiter := ratelimit.NewTokenBucket(clk, 100*time.Millisecond, 2)
+	var wg sync.WaitGroup
+	crawl(url, depth, fetcher, limiter, &wg)
+	// Without waiting, the goroutines for the links would keep running after Crawl returns
+	wg.Wait()
+}
+
+func crawl(url string, depth int, fetcher Fetcher, limiter ratelimit.Limiter, wg *sync.WaitGroup) {
+	if depth <= 0 || !myCache.claim(url) {
 		return
 	}
-	// Fill before fetching, because fetching takes some time and multiple goroutines could start fetching the same URL at the same time
-	myCache.fill(url, "/", nil)
+	limiter.Wait(context.Background())
+	fetchSemaphore.Acquire(context.Background(), 1)
+	end := tracer.Span("fetch " + url)
 	body, urls, err := fetcher.Fetch(url)
+	end()
+	fetchSemaphore.Release(1)
 	if err != nil {
 		fmt.Println(err)
 		return
@@ -213,14 +408,30 @@ func Crawl(url string, depth int, fetcher Fetcher) {
 	fmt.Printf("found: %s %q\n", url, body)
 	myCache.fill(url, body, urls)
 	for _, u := range urls {
-		go Crawl(u, depth-1, fetcher)
+		wg.Add(1)
+		go func(u string) {
+			defer wg.Done()
+			crawl(u, depth-1, fetcher, limiter, wg)
+		}(u)
 	}
 	return
 }
 
+// claim reports whether the URL wasn't crawled yet, and marks it as crawled.
+// Checking and marking must happen while holding the lock, otherwise multiple goroutines could start fetching the same URL at the same time.
+func (c *cache) claim(url string) bool {
+	tracer.Lock(&c.mux, "cache")
+	defer c.mux.Unlock()
+	if _, ok := c.result[url]; ok {
+		return false
+	}
+	c.result[url] = fakeResult{}
+	return true
+}
+
 func (c *cache) fill(url string, body string, urls []string) {
 	// Lock, fill and unlock cache
-	c.mux.Lock()
+	tracer.Lock(&c.mux, "cache")
 	c.result[url] = fakeResult{
 		body: body,
 		urls: urls,
@@ -266,10 +477,19 @@ var fetcher = fakeFetcher{
 // ========
 
 func main() {
+	flag.Parse()
+	if *traceFile != "" {
+		tracer = tracing.New()
+	}
+
 	myGoroutine()
 
 	myChannel()
 
+	myFuture()
+
+	myParallelSum()
+
 	// Buffered channels
 	ch := make(chan int, 2)
 	ch <- 1 // Doesn't block
@@ -284,22 +504,24 @@ func main() {
 
 	myChannelSelect()
 
-	// The "default" case in a select statement is run if no other case is ready
-	tick := time.Tick(100 * time.Millisecond)
-	boom := time.After(500 * time.Millisecond)
-	var br bool
-	for br == false {
-		select {
-		case <-tick:
-			fmt.Println("tick.")
-		case <-boom:
-			fmt.Println("BOOM!")
-			br = true
-		default:
-			fmt.Println("    .")
-			time.Sleep(50 * time.Millisecond)
-		}
-	}
+	myPipeline()
+
+	myPubSub()
+
+	myTickBoom()
+
+	// The same with the "schedule" package, which can run any number of jobs with intervals or cron expressions
+	scheduler := schedule.New(clk)
+	boomed := make(chan struct{})
+	scheduler.Every("tick", 100*time.Millisecond, schedule.Skip, func(ctx context.Context) {
+		fmt.Println("tick.")
+	})
+	scheduler.After("boom", 500*time.Millisecond, func(ctx context.Context) {
+		fmt.Println("BOOM!")
+		close(boomed)
+	})
+	<-boomed
+	scheduler.Stop(context.Background())
 
 	ch = make(chan int, 10) // Exercise says tree.New() creates a tree with 10 values
 	go Walk(tree.New(1), ch)
@@ -317,6 +539,24 @@ func main() {
 
 	myMutex()
 
+	myWorkerPool()
+
 	Crawl("https://golang.org/", 4, fetcher)
-	time.Sleep(time.Second * 2)
+
+	if tracer != nil {
+		f, err := os.Create(*traceFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := tracer.WriteJSON(f); err != nil {
+			log.Fatal(err)
+		}
+		// The trace is only complete if closing the file works, too
+		if err := f.Close(); err != nil {
+			log.Fatal(err)
+		}
+		if err := tracer.WriteTimeline(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+	}
 }
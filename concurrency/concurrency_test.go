@@ -0,0 +1,285 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/philippgille/hello-go/clock"
+	"github.com/philippgille/hello-go/leakcheck"
+	"github.com/philippgille/hello-go/tracing"
+	"golang.org/x/tour/tree"
+)
+
+// useFakeClock replaces "clk" with a clock.Fake for the duration of the test
+func useFakeClock(t *testing.T) *clock.Fake {
+	c := clock.NewFake(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC))
+	clk = c
+	t.Cleanup(func() {
+		clk = clock.Real
+	})
+	return c
+}
+
+// captureStdout returns what f prints
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() {
+		os.Stdout = stdout
+	}()
+	out := make(chan string)
+	go func() {
+		b, _ := io.ReadAll(r)
+		out <- string(b)
+	}()
+	f()
+	w.Close()
+	return <-out
+}
+
+func TestSay(t *testing.T) {
+	c := useFakeClock(t)
+	out := captureStdout(t, func() {
+		go func() {
+			for i := 0; i < 5; i++ {
+				c.BlockUntil(1)
+				c.Advance(100 * time.Millisecond)
+			}
+		}()
+		say("hello")
+	})
+	if want := strings.Repeat("hello\n", 5); out != want {
+		t.Errorf("say printed %q, want %q", out, want)
+	}
+}
+
+func TestTickBoom(t *testing.T) {
+	c := useFakeClock(t)
+	out := captureStdout(t, func() {
+		go func() {
+			// Waits for the ticker, "boom" and the sleep of the default case
+			for i := 0; i < 10; i++ {
+				c.BlockUntil(3)
+				c.Advance(50 * time.Millisecond)
+			}
+		}()
+		myTickBoom()
+	})
+
+	// At 500ms both "tick" and "boom" are ready, and select chooses one at random
+	want := strings.Repeat("    .\n    .\ntick.\n", 4) + "    .\n    .\n"
+	if out != want+"BOOM!\n" && out != want+"tick.\nBOOM!\n" {
+		t.Errorf("myTickBoom printed:\n%s", out)
+	}
+}
+
+// autoAdvance advances the clock by d every millisecond of real time, until the returned function is called
+func autoAdvance(c *clock.Fake, d time.Duration) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(time.Millisecond):
+				c.Advance(d)
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+func TestMyGoroutine(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	c := useFakeClock(t)
+	// The tracer records when "say world" ends, which can be after myGoroutine returned
+	tracer = tracing.New()
+	defer func() {
+		tracer = nil
+	}()
+	out := captureStdout(t, func() {
+		go func() {
+			for i := 0; i < 5; i++ {
+				// Both "say" calls sleep at the same time
+				c.BlockUntil(2)
+				c.Advance(100 * time.Millisecond)
+			}
+		}()
+		myGoroutine()
+		// Waiting for it via the tracer's mutex also prevents a data race between its last print and restoring os.Stdout
+		for !slices.ContainsFunc(tracer.Events(), func(e tracing.Event) bool {
+			return e.Name == "say world"
+		}) {
+			time.Sleep(time.Millisecond)
+		}
+	})
+	if strings.Count(out, "hello\n") != 5 || strings.Count(out, "world\n") != 5 {
+		t.Errorf("myGoroutine printed:\n%s", out)
+	}
+}
+
+// myMutex sleeps for the goroutines to finish instead of waiting for them, so the test only advances the clock once they are done
+func TestMyMutex(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	c := useFakeClock(t)
+	snapshot := leakcheck.Take()
+	out := captureStdout(t, func() {
+		go func() {
+			c.BlockUntil(1)
+			// The second of sleeping only passes after all increments, so the result doesn't depend on the speed of the machine.
+			// The goroutines of this test and of captureStdout are ignored.
+			if leaked := snapshot.Leaked(10*time.Second, "TestMyMutex", "captureStdout"); len(leaked) > 0 {
+				t.Errorf("%v goroutines didn't increment the counter", len(leaked))
+			}
+			c.Advance(time.Second)
+		}()
+		myMutex()
+	})
+	if out != "1000\n" {
+		t.Errorf("myMutex printed %q, want 1000", out)
+	}
+}
+
+// The examples that don't need the clock, and what they print
+func TestExamples(t *testing.T) {
+	examples := []struct {
+		name string
+		f    func()
+		want string
+	}{
+		{"myChannel", myChannel, "-5 17 12\n"},
+		{"myFuture", myFuture, "17 -5 12\n[17 -5] <nil>\n"},
+		{"myChannelRange", myChannelRange, "0\n1\n1\n2\n3\n5\n8\n13\n21\n34\n"},
+		{"myChannelSelect", myChannelSelect, "0\n1\n1\n2\n3\n5\n8\n13\n21\n34\nquit\n"},
+		{"myPipeline", myPipeline, "214 <nil>\n"},
+		{"myPubSub", myPubSub, "golang: golang.pkg.fmt Package fmt\ngolang: golang.cmd Commands\npkg: golang.pkg.fmt Package fmt\n"},
+		{"myWorkerPool", myWorkerPool, "1000\n"},
+	}
+	for _, example := range examples {
+		t.Run(example.name, func(t *testing.T) {
+			defer leakcheck.Check(t)()
+
+			out := captureStdout(t, example.f)
+			// The two halves of myChannel can finish in any order
+			if example.name == "myChannel" && out == "17 -5 12\n" {
+				return
+			}
+			if out != example.want {
+				t.Errorf("printed %q, want %q", out, example.want)
+			}
+		})
+	}
+}
+
+func TestWalk(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	for k := 1; k <= 3; k++ {
+		ch := make(chan int)
+		go func() {
+			Walk(tree.New(k), ch)
+			close(ch)
+		}()
+		var got []int
+		for v := range ch {
+			got = append(got, v)
+		}
+		want := []int{k, 2 * k, 3 * k, 4 * k, 5 * k, 6 * k, 7 * k, 8 * k, 9 * k, 10 * k}
+		if !slices.Equal(got, want) {
+			t.Errorf("Walk(tree.New(%v)) sent %v, want %v", k, got, want)
+		}
+	}
+}
+
+func TestSame(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	one := &tree.Tree{Value: 1}
+	oneTwo := &tree.Tree{Value: 1, Right: &tree.Tree{Value: 2}}
+	twoOne := &tree.Tree{Left: &tree.Tree{Value: 1}, Value: 2}
+	oneThree := &tree.Tree{Value: 1, Right: &tree.Tree{Value: 3}}
+	tests := []struct {
+		t1, t2 *tree.Tree
+		want   bool
+	}{
+		{tree.New(1), tree.New(1), true},
+		{tree.New(1), tree.New(2), false},
+		{tree.New(2), tree.New(1), false},
+		// Same values, different shapes
+		{oneTwo, twoOne, true},
+		// Different number of values, so one walk is still running when Same returns
+		{one, oneTwo, false},
+		{oneTwo, one, false},
+		{oneTwo, oneThree, false},
+		{nil, nil, true},
+		{nil, one, false},
+	}
+	for _, test := range tests {
+		if got := Same(test.t1, test.t2); got != test.want {
+			t.Errorf("Same(%v, %v) = %v, want %v", test.t1, test.t2, got, test.want)
+		}
+	}
+}
+
+// countingFetcher counts the fetches of each URL
+type countingFetcher struct {
+	Fetcher
+	mux    sync.Mutex
+	counts map[string]int
+}
+
+func (f *countingFetcher) Fetch(url string) (string, []string, error) {
+	f.mux.Lock()
+	f.counts[url]++
+	f.mux.Unlock()
+	return f.Fetcher.Fetch(url)
+}
+
+// Run with -race
+func TestCrawl(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	c := useFakeClock(t)
+	stop := autoAdvance(c, 100*time.Millisecond)
+	defer stop()
+	myCache = cache{result: make(map[string]fakeResult)}
+
+	f := &countingFetcher{Fetcher: fetcher, counts: make(map[string]int)}
+	out := captureStdout(t, func() {
+		Crawl("https://golang.org/", 4, f)
+	})
+	for url, n := range f.counts {
+		if n != 1 {
+			t.Errorf("fetched %v %v times", url, n)
+		}
+	}
+	if len(f.counts) != 5 {
+		t.Errorf("fetched %v URLs, want 5", len(f.counts))
+	}
+	for url, res := range fetcher {
+		if line := fmt.Sprintf("found: %s %q\n", url, res.body); !strings.Contains(out, line) {
+			t.Errorf("output doesn't contain %q", line)
+		}
+	}
+	if !strings.Contains(out, "not found: https://golang.org/cmd/\n") {
+		t.Error("output doesn't contain the missing page")
+	}
+}
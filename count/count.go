@@ -0,0 +1,47 @@
+// Package count counts words, characters and letters, like "WordCount" in the moretypes chapter.
+package count
+
+import (
+	"iter"
+	"strings"
+)
+
+// Of counts how often each value occurs in the sequence.
+func Of[K comparable](seq iter.Seq[K]) map[K]int {
+	result := make(map[K]int)
+	for v := range seq {
+		result[v]++
+	}
+	return result
+}
+
+// Words counts the words of s, which are separated by any amount of white space.
+func Words(s string) map[string]int {
+	return Of(strings.FieldsSeq(s))
+}
+
+// Runes counts the characters (Unicode code points) of s.
+func Runes(s string) map[rune]int {
+	return Of(func(yield func(rune) bool) {
+		for _, r := range s {
+			if !yield(r) {
+				return
+			}
+		}
+	})
+}
+
+// Letters counts the ASCII letters of s, ignoring the case.
+// Index 0 is the count of "a" and "A", index 25 the one of "z" and "Z". Other characters aren't counted.
+func Letters(s string) [26]int {
+	var result [26]int
+	for i := 0; i < len(s); i++ {
+		switch b := s[i]; {
+		case 'a' <= b && b <= 'z':
+			result[b-'a']++
+		case 'A' <= b && b <= 'Z':
+			result[b-'A']++
+		}
+	}
+	return result
+}
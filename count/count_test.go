@@ -0,0 +1,92 @@
+package count
+
+import (
+	"maps"
+	"slices"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestOf(t *testing.T) {
+	if got, want := Of(slices.Values([]int{3, 1, 3, 3, 2})), map[int]int{1: 1, 2: 1, 3: 3}; !maps.Equal(got, want) {
+		t.Errorf("Of(ints) = %v, want %v", got, want)
+	}
+	if got := Of(slices.Values([]string(nil))); got == nil || len(got) != 0 {
+		t.Errorf("Of(empty) = %#v, want an empty map", got)
+	}
+	// Any comparable type works
+	type point struct{ x, y int }
+	if got := Of(slices.Values([]point{{1, 2}, {1, 2}, {2, 1}})); got[point{1, 2}] != 2 || got[point{2, 1}] != 1 {
+		t.Errorf("Of(points) = %v", got)
+	}
+}
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		s    string
+		want map[string]int
+	}{
+		{"", map[string]int{}},
+		{" \t\n ", map[string]int{}},
+		{"go", map[string]int{"go": 1}},
+		{"I am learning  Go!\tGo is fun", map[string]int{"I": 1, "am": 1, "learning": 1, "Go!": 1, "Go": 1, "is": 1, "fun": 1}},
+		// Words are case sensitive and include punctuation
+		{"go Go go.", map[string]int{"go": 1, "Go": 1, "go.": 1}},
+		{"\r\ngo\v\fgo\n", map[string]int{"go": 2}},
+		// Unicode white space like no-break space, ideographic space and line separator
+		{"über\u00a0über\u3000straße\u2028über", map[string]int{"über": 3, "straße": 1}},
+		{"日本語 日本語", map[string]int{"日本語": 2}},
+	}
+	for _, tt := range tests {
+		if got := Words(tt.s); !maps.Equal(got, tt.want) {
+			t.Errorf("Words(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestRunes(t *testing.T) {
+	tests := []struct {
+		s    string
+		want map[rune]int
+	}{
+		{"", map[rune]int{}},
+		{"aab", map[rune]int{'a': 2, 'b': 1}},
+		{"Gö Go", map[rune]int{'G': 2, 'ö': 1, 'o': 1, ' ': 1}},
+		{"日本\t日", map[rune]int{'日': 2, '本': 1, '\t': 1}},
+		// Invalid UTF-8 is counted as the replacement character, once per byte
+		{"a\xff\xfe", map[rune]int{'a': 1, utf8.RuneError: 2}},
+		// Combining characters are separate code points
+		{"e\u0301", map[rune]int{'e': 1, '\u0301': 1}},
+	}
+	for _, tt := range tests {
+		if got := Runes(tt.s); !maps.Equal(got, tt.want) {
+			t.Errorf("Runes(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestLetters(t *testing.T) {
+	letters := func(counts map[byte]int) [26]int {
+		var result [26]int
+		for b, n := range counts {
+			result[b-'a'] = n
+		}
+		return result
+	}
+	tests := []struct {
+		s    string
+		want [26]int
+	}{
+		{"", [26]int{}},
+		{"Hello, Go!", letters(map[byte]int{'h': 1, 'e': 1, 'l': 2, 'o': 2, 'g': 1})},
+		{"AaZz", letters(map[byte]int{'a': 2, 'z': 2})},
+		// Digits, white space and non-ASCII letters aren't counted
+		{"123 \t\n", [26]int{}},
+		{"Göße", letters(map[byte]int{'g': 1, 'e': 1})},
+	}
+	for _, tt := range tests {
+		if got := Letters(tt.s); got != tt.want {
+			t.Errorf("Letters(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+// Package crack recovers the keys of Caesar and Vigenère ciphertext with frequency analysis,
+// like the ROT13 ciphertext of "rot13Reader" in the methods chapter, without knowing the shift.
+// It works for English text, and the longer the text, the better.
+package crack
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/philippgille/hello-go/cipher"
+	"github.com/philippgille/hello-go/count"
+)
+
+// English letter frequencies, from "a" to "z"
+var english = [26]float64{
+	0.08167, 0.01492, 0.02782, 0.04253, 0.12702, 0.02228, 0.02015, 0.06094, 0.06966, 0.00153, 0.00772, 0.04025, 0.02406,
+	0.06749, 0.07507, 0.01929, 0.00095, 0.05987, 0.06327, 0.09056, 0.02758, 0.00978, 0.02360, 0.00150, 0.01974, 0.00074,
+}
+
+// EnglishIoC is the index of coincidence of English text.
+// For random letters it's 1/26 ≈ 0.0385.
+const EnglishIoC = 0.0667
+
+// Candidate is a possible decryption.
+type Candidate struct {
+	// Key is the Vigenère key. For Caesar it's the letter of the shift, like "d" for 3 or "n" for ROT13.
+	Key       string
+	Plaintext string
+	// Score is the chi-squared statistic of the plaintext letters compared to English. Lower is better.
+	Score float64
+}
+
+// ChiSquared returns the chi-squared statistic of the letter counts compared to English letter frequencies.
+// It's 0 for a perfect match and larger the more the counts differ.
+func ChiSquared(letters [26]int) float64 {
+	total := 0
+	for _, n := range letters {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+	chi := 0.0
+	for i, n := range letters {
+		expected := float64(total) * english[i]
+		d := float64(n) - expected
+		chi += d * d / expected
+	}
+	return chi
+}
+
+// IndexOfCoincidence returns the probability that two randomly chosen letters are the same.
+// It's about EnglishIoC for English text and for text encrypted with a single shift, and lower for Vigenère ciphertext.
+func IndexOfCoincidence(letters [26]int) float64 {
+	total, sum := 0, 0
+	for _, n := range letters {
+		total += n
+		sum += n * (n - 1)
+	}
+	if total < 2 {
+		return 0
+	}
+	return float64(sum) / float64(total*(total-1))
+}
+
+// ========
+
+// Caesar returns the decryptions with all 26 shifts, the most likely one first.
+func Caesar(ciphertext string) []Candidate {
+	letters := count.Letters(ciphertext)
+	candidates := make([]Candidate, 26)
+	for shift := range candidates {
+		key := string(rune('a' + shift))
+		candidates[shift] = Candidate{
+			Key:       key,
+			Plaintext: cipher.Decrypt(cipher.Caesar(shift), ciphertext),
+			Score:     ChiSquared(shifted(letters, shift)),
+		}
+	}
+	sortCandidates(candidates)
+	return candidates
+}
+
+// shifted returns the letter counts after decrypting with the shift, without decrypting the text
+func shifted(letters [26]int, shift int) [26]int {
+	var result [26]int
+	for i, n := range letters {
+		result[(i-shift+26)%26] = n
+	}
+	return result
+}
+
+// bestShift returns the shift that makes the letter counts most similar to English
+func bestShift(letters [26]int) int {
+	best, bestScore := 0, 0.0
+	for shift := 0; shift < 26; shift++ {
+		if score := ChiSquared(shifted(letters, shift)); shift == 0 || score < bestScore {
+			best, bestScore = shift, score
+		}
+	}
+	return best
+}
+
+// ========
+
+// Vigenere returns the most likely decryptions with keys of up to maxKeyLen letters, the most likely one first.
+// For each of the most likely key lengths (see KeyLengths) each letter of the key is determined like a Caesar shift.
+// It returns nil if maxKeyLen < 1.
+func Vigenere(ciphertext string, maxKeyLen int) []Candidate {
+	letters := onlyLetters(ciphertext)
+	lengths := KeyLengths(ciphertext, maxKeyLen)
+	if len(lengths) > 5 {
+		lengths = lengths[:5]
+	}
+
+	var candidates []Candidate
+	seen := map[string]bool{}
+	for _, l := range lengths {
+		key := make([]byte, l)
+		for i := range key {
+			key[i] = byte('a' + bestShift(count.Letters(column(letters, i, l))))
+		}
+		k := period(string(key))
+		// A multiple of the key length leads to the key repeated, which was already found with the shorter length
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		c, _ := cipher.Vigenere(k)
+		plaintext := cipher.Decrypt(c, ciphertext)
+		candidates = append(candidates, Candidate{k, plaintext, ChiSquared(count.Letters(plaintext))})
+	}
+	sortCandidates(candidates)
+	return candidates
+}
+
+// KeyLengths returns the Vigenère key lengths from 1 to maxKeyLen, the most likely one first.
+//
+// Two methods are combined:
+// With the right key length, the letters at every l-th position are encrypted with the same shift,
+// so their index of coincidence is about EnglishIoC (and lower for wrong lengths).
+// And the Kasiski examination: Repeated sequences in the ciphertext are often the same plaintext encrypted with the same part of the key,
+// so their distances are multiples of the key length.
+//
+// It returns nil if maxKeyLen < 1.
+func KeyLengths(ciphertext string, maxKeyLen int) []int {
+	if maxKeyLen < 1 {
+		return nil
+	}
+	letters := onlyLetters(ciphertext)
+	if maxKeyLen > len(letters)/2 {
+		maxKeyLen = max(1, len(letters)/2)
+	}
+	distances := repeatDistances(letters)
+
+	scores := make(map[int]float64, maxKeyLen)
+	lengths := make([]int, 0, maxKeyLen)
+	for l := 1; l <= maxKeyLen; l++ {
+		ioc := 0.0
+		for i := 0; i < l; i++ {
+			ioc += IndexOfCoincidence(count.Letters(column(letters, i, l)))
+		}
+		ioc /= float64(l)
+
+		// Share of the distances that are multiples of l.
+		// It's 1 for l = 1, but then the index of coincidence is low.
+		kasiski := 0.0
+		if len(distances) > 0 {
+			multiples := 0
+			for _, d := range distances {
+				if d%l == 0 {
+					multiples++
+				}
+			}
+			kasiski = float64(multiples) / float64(len(distances))
+		}
+		// Multiples of the key length have about the same index of coincidence, but fewer distances are multiples of them
+		scores[l] = ioc * (1 + kasiski)
+		lengths = append(lengths, l)
+	}
+	sort.SliceStable(lengths, func(i, j int) bool {
+		return scores[lengths[i]] > scores[lengths[j]]
+	})
+	return lengths
+}
+
+// repeatDistances returns the distances between repeated sequences of 3 letters
+func repeatDistances(letters string) []int {
+	last := map[string]int{}
+	var distances []int
+	for i := 0; i+3 <= len(letters); i++ {
+		seq := letters[i : i+3]
+		if j, ok := last[seq]; ok {
+			distances = append(distances, i-j)
+		}
+		last[seq] = i
+	}
+	return distances
+}
+
+// onlyLetters returns the letters of s, in lower case, because only letters use up the Vigenère key
+func onlyLetters(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch b := s[i]; {
+		case 'a' <= b && b <= 'z':
+			sb.WriteByte(b)
+		case 'A' <= b && b <= 'Z':
+			sb.WriteByte(b - 'A' + 'a')
+		}
+	}
+	return sb.String()
+}
+
+// column returns every l-th letter, starting at i
+func column(letters string, i, l int) string {
+	var sb strings.Builder
+	for ; i < len(letters); i += l {
+		sb.WriteByte(letters[i])
+	}
+	return sb.String()
+}
+
+// period returns the shortest string that key is a repetition of, like "abc" for "abcabc"
+func period(key string) string {
+	for l := 1; l < len(key); l++ {
+		if len(key)%l == 0 && strings.Repeat(key[:l], len(key)/l) == key {
+			return key[:l]
+		}
+	}
+	return key
+}
+
+// sortCandidates sorts by score, and with the same score by shorter key
+func sortCandidates(candidates []Candidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score < candidates[j].Score
+		}
+		return len(candidates[i].Key) < len(candidates[j].Key)
+	})
+}
@@ -0,0 +1,116 @@
+package crack
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/philippgille/hello-go/cipher"
+	"github.com/philippgille/hello-go/count"
+)
+
+// About 600 letters of English text
+const sample = `The Go programming language is an open source project to make programmers more productive.
+Go is expressive, concise, clean, and efficient. Its concurrency mechanisms make it easy to write programs
+that get the most out of multicore and networked machines, while its novel type system enables flexible
+and modular program construction. Go compiles quickly to machine code yet has the convenience of garbage
+collection and the power of run-time reflection. It's a fast, statically typed, compiled language that
+feels like a dynamically typed, interpreted language. A tour of the language starts with packages,
+variables and functions, continues with flow control statements and more types like structs, slices
+and maps, and ends with methods, interfaces, generics and concurrency.`
+
+func TestCaesar(t *testing.T) {
+	candidates := Caesar("Lbh penpxrq gur pbqr!")
+	if len(candidates) != 26 {
+		t.Fatalf("Caesar() returned %v candidates, want 26", len(candidates))
+	}
+	if c := candidates[0]; c.Key != "n" || c.Plaintext != "You cracked the code!" {
+		t.Errorf("best candidate = %+v, want key n and \"You cracked the code!\"", c)
+	}
+	for i := 1; i < len(candidates); i++ {
+		if candidates[i].Score < candidates[i-1].Score {
+			t.Errorf("candidates aren't sorted by score: %v before %v", candidates[i-1].Score, candidates[i].Score)
+		}
+	}
+
+	for shift := 0; shift < 26; shift++ {
+		c := Caesar(cipher.Encrypt(cipher.Caesar(shift), sample))[0]
+		if want := string(rune('a' + shift)); c.Key != want || c.Plaintext != sample {
+			t.Errorf("best key for shift %v = %q, want %q", shift, c.Key, want)
+		}
+	}
+}
+
+func TestVigenere(t *testing.T) {
+	for _, key := range []string{"lemon", "gopher", "cryptography", "Go"} {
+		c, err := cipher.Vigenere(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ciphertext := cipher.Encrypt(c, sample)
+		candidates := Vigenere(ciphertext, 15)
+		if len(candidates) == 0 {
+			t.Fatalf("Vigenere() found nothing for key %q", key)
+		}
+		if best := candidates[0]; best.Key != strings.ToLower(key) || best.Plaintext != sample {
+			t.Errorf("best key = %q, want %q", best.Key, strings.ToLower(key))
+		}
+		if l := KeyLengths(ciphertext, 15)[0]; l%len(key) != 0 {
+			t.Errorf("most likely key length for %q = %v", key, l)
+		}
+	}
+	// Plain English has the key "a"
+	if best := Vigenere(sample, 10)[0]; best.Key != "a" {
+		t.Errorf("best key for plaintext = %q, want \"a\"", best.Key)
+	}
+	if got := Vigenere("", 10); len(got) > 1 {
+		t.Errorf("Vigenere(\"\") = %v", got)
+	}
+	// Key lengths below 1 don't exist
+	for _, maxKeyLen := range []int{0, -1} {
+		if got := Vigenere(sample, maxKeyLen); got != nil {
+			t.Errorf("Vigenere() with maxKeyLen %v = %v, want nil", maxKeyLen, got)
+		}
+		if got := KeyLengths(sample, maxKeyLen); got != nil {
+			t.Errorf("KeyLengths() with maxKeyLen %v = %v, want nil", maxKeyLen, got)
+		}
+	}
+	// maxKeyLen is limited to half of the letters
+	if got := KeyLengths("abcdef", 10); len(got) != 3 {
+		t.Errorf("KeyLengths() of 6 letters = %v, want 3 lengths", got)
+	}
+}
+
+func TestStatistics(t *testing.T) {
+	english := count.Letters(sample)
+	if ioc := IndexOfCoincidence(english); math.Abs(ioc-EnglishIoC) > 0.01 {
+		t.Errorf("IndexOfCoincidence() of English = %v, want about %v", ioc, EnglishIoC)
+	}
+	var uniform [26]int
+	for i := range uniform {
+		uniform[i] = 10
+	}
+	if ioc := IndexOfCoincidence(uniform); math.Abs(ioc-1.0/26) > 0.005 {
+		t.Errorf("IndexOfCoincidence() of uniform letters = %v, want about %v", ioc, 1.0/26)
+	}
+	if ioc := IndexOfCoincidence([26]int{1}); ioc != 0 {
+		t.Errorf("IndexOfCoincidence() of one letter = %v", ioc)
+	}
+	if chi := ChiSquared([26]int{}); chi != 0 {
+		t.Errorf("ChiSquared() of nothing = %v", chi)
+	}
+	if en, un := ChiSquared(english), ChiSquared(uniform); en >= un {
+		t.Errorf("ChiSquared() of English = %v, of uniform letters = %v", en, un)
+	}
+
+	periods := map[string]string{"abcabc": "abc", "aaaa": "a", "abab": "ab", "abcab": "abcab", "x": "x"}
+	for key, want := range periods {
+		if got := period(key); got != want {
+			t.Errorf("period(%q) = %q, want %q", key, got, want)
+		}
+	}
+	if got := repeatDistances("abcxxabcyyabc"); !slices.Equal(got, []int{5, 5}) {
+		t.Errorf("repeatDistances() = %v, want [5 5]", got)
+	}
+}
@@ -0,0 +1,268 @@
+// Package errs provides structured errors with a code, key/value fields, a cause and an optional stack trace,
+// like "myError" in the methods chapter, which only has a timestamp and a message.
+// Errors work with errors.Is, errors.As and errors.Unwrap of the standard library.
+package errs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"time"
+)
+
+// Code is a category of errors, like an HTTP status code.
+// It implements error, so errors.Is(err, errs.NotFound) reports whether err or one of its causes has the code.
+type Code string
+
+// Error implements error.
+func (c Code) Error() string {
+	return string(c)
+}
+
+// Codes for common categories of errors
+const (
+	Unknown          Code = "unknown"
+	InvalidArgument  Code = "invalid_argument"
+	NotFound         Code = "not_found"
+	AlreadyExists    Code = "already_exists"
+	PermissionDenied Code = "permission_denied"
+	Unavailable      Code = "unavailable"
+	Internal         Code = "internal"
+)
+
+// Field is a key/value pair with details about an error.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Error is a structured error. Create it with New or Wrap, or NewWithStack or WrapWithStack to include a stack trace.
+type Error struct {
+	Code    Code
+	Message string
+	When    time.Time
+	Fields  []Field
+	Cause   error
+	stack   []uintptr
+}
+
+// New returns an error with the code and message.
+// keysAndValues are alternating keys and values, like New(NotFound, "no such user", "id", 42).
+func New(code Code, message string, keysAndValues ...interface{}) *Error {
+	return &Error{
+		Code:    code,
+		Message: message,
+		When:    time.Now(),
+		Fields:  fields(keysAndValues),
+	}
+}
+
+// Wrap returns an error with the code and message that's caused by err.
+// The message can be empty, then Error() returns the message of the cause.
+// Wrap returns nil if err is nil, so it can be used like "return errs.Wrap(f(), ...)".
+// That's why the result is an error and not *Error: a nil *Error in an error interface isn't nil.
+func Wrap(err error, code Code, message string, keysAndValues ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	e := New(code, message, keysAndValues...)
+	e.Cause = err
+	return e
+}
+
+// fields converts alternating keys and values to fields.
+// Keys that aren't strings are formatted with %v, and a missing last value becomes nil.
+func fields(keysAndValues []interface{}) []Field {
+	var result []Field
+	for i := 0; i < len(keysAndValues); i += 2 {
+		f := Field{Key: fmt.Sprint(keysAndValues[i])}
+		if i+1 < len(keysAndValues) {
+			f.Value = keysAndValues[i+1]
+		}
+		result = append(result, f)
+	}
+	return result
+}
+
+// NewWithStack is like New, but also captures the stack trace of its caller, see Stack.
+func NewWithStack(code Code, message string, keysAndValues ...interface{}) *Error {
+	e := New(code, message, keysAndValues...)
+	e.stack = callers()
+	return e
+}
+
+// WrapWithStack is like Wrap, but also captures the stack trace of its caller, see Stack.
+func WrapWithStack(err error, code Code, message string, keysAndValues ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	e := New(code, message, keysAndValues...)
+	e.Cause = err
+	e.stack = callers()
+	return e
+}
+
+// With returns a copy of e with additional fields.
+func (e *Error) With(keysAndValues ...interface{}) *Error {
+	c := *e
+	c.Fields = append(append([]Field(nil), e.Fields...), fields(keysAndValues)...)
+	return &c
+}
+
+// WithStack returns a copy of e with the stack trace of the caller of WithStack.
+// That's where WithStack is called, not where e was created, so prefer NewWithStack and WrapWithStack.
+// Capturing the stack is relatively expensive, so it's optional.
+func (e *Error) WithStack() *Error {
+	c := *e
+	c.stack = callers()
+	return &c
+}
+
+// callers returns the stack trace of the caller of the function that calls callers
+func callers() []uintptr {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs) // Skip runtime.Callers, callers and its caller
+	return pcs[:n]
+}
+
+// Stack returns the stack trace captured by NewWithStack, WrapWithStack or WithStack, or nil.
+func (e *Error) Stack() []runtime.Frame {
+	if len(e.stack) == 0 {
+		return nil
+	}
+	var result []runtime.Frame
+	frames := runtime.CallersFrames(e.stack)
+	for {
+		f, more := frames.Next()
+		result = append(result, f)
+		if !more {
+			return result
+		}
+	}
+}
+
+// Error implements error. It returns the message and the message of the cause, like "loading config: file not found".
+// Code, fields and stack aren't included, use "%+v" or JSON for them.
+func (e *Error) Error() string {
+	switch {
+	case e.Cause == nil:
+		return e.Message
+	case e.Message == "":
+		return e.Cause.Error()
+	}
+	return e.Message + ": " + e.Cause.Error()
+}
+
+// Unwrap returns the cause, for errors.Is, errors.As and errors.Unwrap.
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// Is reports whether target is the Code of e. It's called by errors.Is.
+func (e *Error) Is(target error) bool {
+	c, ok := target.(Code)
+	return ok && e.Code == c
+}
+
+// Format implements fmt.Formatter.
+// "%v" and "%s" print the same as Error(), "%+v" prints all details including the causes.
+func (e *Error) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') {
+		e.writeDetails(s, "")
+		return
+	}
+	io.WriteString(s, e.Error())
+}
+
+func (e *Error) writeDetails(w io.Writer, indent string) {
+	fmt.Fprintf(w, "%v%v\n", indent, e.Message)
+	fmt.Fprintf(w, "%v    code: %v\n", indent, e.Code)
+	fmt.Fprintf(w, "%v    when: %v\n", indent, e.When.Format(time.RFC3339Nano))
+	for _, f := range e.Fields {
+		fmt.Fprintf(w, "%v    %v: %v\n", indent, f.Key, f.Value)
+	}
+	if frames := e.Stack(); frames != nil {
+		fmt.Fprintf(w, "%v    stack:\n", indent)
+		for _, f := range frames {
+			fmt.Fprintf(w, "%v        %v\n%v            %v:%v\n", indent, f.Function, indent, f.File, f.Line)
+		}
+	}
+	if e.Cause != nil {
+		fmt.Fprintf(w, "%vcaused by:\n", indent)
+		if c, ok := e.Cause.(*Error); ok {
+			c.writeDetails(w, indent+"    ")
+		} else {
+			fmt.Fprintf(w, "%v    %v\n", indent, e.Cause)
+		}
+	}
+}
+
+// ========
+
+type jsonError struct {
+	Code    Code                   `json:"code,omitempty"`
+	Message string                 `json:"message"`
+	When    *time.Time             `json:"when,omitempty"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+	Stack   []string               `json:"stack,omitempty"`
+	Cause   interface{}            `json:"cause,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+// A cause that's an *Error is included as nested object, other causes only with their message.
+// Field values must be encodable as JSON.
+func (e *Error) MarshalJSON() ([]byte, error) {
+	j := jsonError{Code: e.Code, Message: e.Message}
+	if !e.When.IsZero() {
+		j.When = &e.When
+	}
+	if len(e.Fields) > 0 {
+		j.Fields = make(map[string]interface{}, len(e.Fields))
+		for _, f := range e.Fields {
+			j.Fields[f.Key] = f.Value
+		}
+	}
+	for _, f := range e.Stack() {
+		j.Stack = append(j.Stack, fmt.Sprintf("%v %v:%v", f.Function, f.File, f.Line))
+	}
+	if c, ok := e.Cause.(*Error); ok {
+		j.Cause = c
+	} else if e.Cause != nil {
+		j.Cause = jsonError{Message: e.Cause.Error()}
+	}
+	return json.Marshal(j)
+}
+
+// ========
+
+// CodeOf returns the code of the outermost *Error in the chain of err.
+// It's Unknown if there's none, and "" if err is nil.
+func CodeOf(err error) Code {
+	if err == nil {
+		return ""
+	}
+	for ; err != nil; err = errors.Unwrap(err) {
+		if e, ok := err.(*Error); ok && e.Code != "" {
+			return e.Code
+		}
+	}
+	return Unknown
+}
+
+// FieldsOf returns the fields of all *Error values in the chain of err.
+// Fields of outer errors take precedence over fields of their causes with the same key.
+func FieldsOf(err error) map[string]interface{} {
+	result := map[string]interface{}{}
+	for ; err != nil; err = errors.Unwrap(err) {
+		if e, ok := err.(*Error); ok {
+			for _, f := range e.Fields {
+				if _, exists := result[f.Key]; !exists {
+					result[f.Key] = f.Value
+				}
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,200 @@
+package errs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type myError struct {
+	what string
+}
+
+func (e *myError) Error() string {
+	return e.what
+}
+
+func TestIsAsUnwrap(t *testing.T) {
+	cause := &myError{"disk full"}
+	inner := Wrap(cause, Unavailable, "writing file")
+	outer := Wrap(fmt.Errorf("saving: %w", inner), Internal, "request failed")
+
+	if got, want := outer.Error(), "request failed: saving: writing file: disk full"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	for _, target := range []error{Internal, Unavailable, cause, inner} {
+		if !errors.Is(outer, target) {
+			t.Errorf("errors.Is(%v) = false", target)
+		}
+	}
+	for _, target := range []error{NotFound, fs.ErrNotExist} {
+		if errors.Is(outer, target) {
+			t.Errorf("errors.Is(%v) = true", target)
+		}
+	}
+
+	var me *myError
+	if !errors.As(outer, &me) || me != cause {
+		t.Errorf("errors.As(*myError) = %v", me)
+	}
+	// The first *Error is the outermost one
+	var e *Error
+	if !errors.As(outer, &e) || e.Code != Internal {
+		t.Errorf("errors.As(*Error) = %v", e)
+	}
+	if u := errors.Unwrap(inner); u != cause {
+		t.Errorf("Unwrap() = %v, want %v", u, cause)
+	}
+	if u := errors.Unwrap(New(NotFound, "no such user")); u != nil {
+		t.Errorf("Unwrap() without cause = %v, want nil", u)
+	}
+
+	// Wrap of nil is a nil error and not a nil *Error in an error interface
+	if err := Wrap(nil, Internal, "x"); err != nil {
+		t.Errorf("Wrap(nil) = %#v, want nil", err)
+	}
+	if err := WrapWithStack(nil, Internal, "x"); err != nil {
+		t.Errorf("WrapWithStack(nil) = %#v, want nil", err)
+	}
+	// An empty message keeps the message of the cause
+	if got := Wrap(cause, Internal, "").Error(); got != "disk full" {
+		t.Errorf("Error() with empty message = %q", got)
+	}
+}
+
+func TestCodeOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Code
+	}{
+		{"nil", nil, ""},
+		{"plain error", errors.New("x"), Unknown},
+		{"Error", New(NotFound, "x"), NotFound},
+		{"outermost code", Wrap(New(NotFound, "x"), PermissionDenied, "y"), PermissionDenied},
+		{"wrapped by fmt", fmt.Errorf("y: %w", New(NotFound, "x")), NotFound},
+		{"empty code", Wrap(New(NotFound, "x"), "", "y"), NotFound},
+		{"only empty codes", New("", "x"), Unknown},
+	}
+	for _, test := range tests {
+		if got := CodeOf(test.err); got != test.want {
+			t.Errorf("%v: CodeOf() = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestFieldsOf(t *testing.T) {
+	inner := New(NotFound, "no such user", "id", 42, "table", "users")
+	outer := Wrap(fmt.Errorf("loading: %w", inner), Internal, "request failed", "id", "outer", "path", "/users/42")
+	want := map[string]interface{}{"id": "outer", "table": "users", "path": "/users/42"}
+	if got := FieldsOf(outer); !reflect.DeepEqual(got, want) {
+		t.Errorf("FieldsOf() = %v, want %v", got, want)
+	}
+	if got := FieldsOf(errors.New("x")); len(got) != 0 {
+		t.Errorf("FieldsOf() of a plain error = %v", got)
+	}
+
+	// Keys that aren't strings are formatted, a missing value is nil
+	e := New(Internal, "x", 1, "a", "b")
+	if want := []Field{{"1", "a"}, {"b", nil}}; !reflect.DeepEqual(e.Fields, want) {
+		t.Errorf("Fields = %v, want %v", e.Fields, want)
+	}
+
+	// With doesn't modify the original
+	w := inner.With("extra", true)
+	if len(inner.Fields) != 2 || len(w.Fields) != 3 || w.Fields[2] != (Field{"extra", true}) {
+		t.Errorf("With() = %v, original %v", w.Fields, inner.Fields)
+	}
+}
+
+// newHere and wrapHere create errors, so the tests can check that their stack trace starts here
+func newHere() *Error {
+	return NewWithStack(NotFound, "x")
+}
+
+func wrapHere() error {
+	return WrapWithStack(errors.New("x"), NotFound, "")
+}
+
+func TestStack(t *testing.T) {
+	if s := New(NotFound, "x").Stack(); s != nil {
+		t.Errorf("Stack() without stack = %v", s)
+	}
+	var wrapped *Error
+	errors.As(wrapHere(), &wrapped)
+	tests := []struct {
+		name  string
+		err   *Error
+		first string // Function of the first frame
+	}{
+		{"NewWithStack", newHere(), "errs.newHere"},
+		{"WrapWithStack", wrapped, "errs.wrapHere"},
+		{"WithStack", New(NotFound, "x").WithStack(), "errs.TestStack"},
+	}
+	for _, test := range tests {
+		frames := test.err.Stack()
+		if len(frames) < 2 {
+			t.Fatalf("%v: Stack() = %v", test.name, frames)
+		}
+		if !strings.HasSuffix(frames[0].Function, test.first) || !strings.HasSuffix(frames[0].File, "errs_test.go") {
+			t.Errorf("%v: first frame is %v in %v, want %v", test.name, frames[0].Function, frames[0].File, test.first)
+		}
+	}
+	// The caller of newHere comes next
+	if f := newHere().Stack()[1].Function; !strings.HasSuffix(f, "errs.TestStack") {
+		t.Errorf("second frame is %v, want TestStack", f)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	err := Wrap(NewWithStack(NotFound, "no such user", "id", 42), Internal, "request failed")
+	if got := fmt.Sprintf("%v|%s", err, err); got != "request failed: no such user|request failed: no such user" {
+		t.Errorf("%%v|%%s = %q", got)
+	}
+	details := fmt.Sprintf("%+v", err)
+	for _, want := range []string{"request failed\n", "    code: internal\n", "caused by:\n", "    no such user\n", "        code: not_found\n", "        id: 42\n", "        stack:\n", "errs.TestFormat"} {
+		if !strings.Contains(details, want) {
+			t.Errorf("%%+v doesn't contain %q:\n%v", want, details)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	err := Wrap(Wrap(errors.New("disk full"), Unavailable, "writing file", "path", "/tmp/x"), Internal, "request failed", "attempt", 2)
+	data, jsonErr := json.Marshal(err)
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["code"] != "internal" || got["message"] != "request failed" || got["when"] == nil || got["stack"] != nil {
+		t.Errorf("Marshal() = %s", data)
+	}
+	if fields := got["fields"].(map[string]interface{}); fields["attempt"] != 2.0 {
+		t.Errorf("fields = %v", fields)
+	}
+	// Nested *Error causes are objects, other causes only have a message
+	cause := got["cause"].(map[string]interface{})
+	if cause["code"] != "unavailable" || cause["fields"].(map[string]interface{})["path"] != "/tmp/x" {
+		t.Errorf("cause = %v", cause)
+	}
+	if want := map[string]interface{}{"message": "disk full"}; !reflect.DeepEqual(cause["cause"], want) {
+		t.Errorf("cause of cause = %v, want %v", cause["cause"], want)
+	}
+
+	// Without time, fields and cause only code and message are set
+	data, _ = json.Marshal(&Error{Code: NotFound, Message: "x"})
+	if want := `{"code":"not_found","message":"x"}`; string(data) != want {
+		t.Errorf("Marshal() = %s, want %s", data, want)
+	}
+	data, _ = json.Marshal(NewWithStack(NotFound, "x"))
+	if !strings.Contains(string(data), `"stack":["github.com/philippgille/hello-go/errs.TestJSON `) {
+		t.Errorf("Marshal() with stack = %s", data)
+	}
+}
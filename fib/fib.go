@@ -0,0 +1,113 @@
+// Package fib calculates Fibonacci numbers without overflowing,
+// starting with F(0) = 0 and F(1) = 1.
+// It's used by the Fibonacci examples of the moretypes and concurrency chapters.
+package fib
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"math/big"
+)
+
+// ErrOverflow is returned by Int when F(n) doesn't fit into an int.
+var ErrOverflow = errors.New("fib: result overflows int")
+
+// Big returns F(n).
+// It uses "fast doubling", which only needs O(log n) steps instead of the n steps of the loop in the examples:
+//
+//	F(2k)   = F(k) * (2*F(k+1) - F(k))
+//	F(2k+1) = F(k)^2 + F(k+1)^2
+//
+// Negative n are allowed, with F(-n) = (-1)^(n+1) * F(n).
+func Big(n int) *big.Int {
+	neg := n < 0
+	if neg {
+		n = -n
+	}
+	a, b := big.NewInt(0), big.NewInt(1) // F(k), F(k+1), starting with k = 0
+	t1, t2 := new(big.Int), new(big.Int)
+	for bit := highestBit(n); bit > 0; bit >>= 1 {
+		// k -> 2k
+		t1.Lsh(b, 1).Sub(t1, a).Mul(t1, a) // F(2k)
+		t2.Mul(b, b).Add(t2, a.Mul(a, a))  // F(2k+1)
+		a, t1 = t1, a
+		b, t2 = t2, b
+		// 2k -> 2k+1
+		if n&bit != 0 {
+			a.Add(a, b)
+			a, b = b, a
+		}
+	}
+	if neg && n%2 == 0 {
+		a.Neg(a)
+	}
+	return a
+}
+
+// Int returns F(n), or ErrOverflow if it doesn't fit into an int (n > 92 for 64 bit ints).
+func Int(n int) (int, error) {
+	f := Big(n)
+	if !f.IsInt64() || int64(int(f.Int64())) != f.Int64() {
+		return 0, ErrOverflow
+	}
+	return int(f.Int64()), nil
+}
+
+// ========
+
+// Closure returns a function that returns the next Fibonacci number on each call,
+// starting with F(0).
+func Closure() func() *big.Int {
+	a, b := big.NewInt(0), big.NewInt(1)
+	return func() *big.Int {
+		result := new(big.Int).Set(a)
+		a.Add(a, b)
+		a, b = b, a
+		return result
+	}
+}
+
+// Seq returns an iterator over the first n Fibonacci numbers, starting with F(0).
+// If n is negative, the sequence is infinite.
+func Seq(n int) iter.Seq[*big.Int] {
+	return func(yield func(*big.Int) bool) {
+		next := Closure()
+		for i := 0; n < 0 || i < n; i++ {
+			if !yield(next()) {
+				return
+			}
+		}
+	}
+}
+
+// Chan sends the first n Fibonacci numbers to the returned channel and then closes it.
+// If n is negative, it sends numbers until ctx is done.
+func Chan(ctx context.Context, n int) <-chan *big.Int {
+	c := make(chan *big.Int)
+	go func() {
+		defer close(c)
+		for f := range Seq(n) {
+			select {
+			case c <- f:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return c
+}
+
+// ========
+
+// highestBit returns the value of the highest set bit of n, or 0 for n == 0
+func highestBit(n int) int {
+	if n == 0 {
+		return 0
+	}
+	bit := 1
+	for bit <= n>>1 {
+		bit <<= 1
+	}
+	return bit
+}
@@ -0,0 +1,110 @@
+package fib
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strconv"
+	"testing"
+
+	"github.com/philippgille/hello-go/leakcheck"
+)
+
+// iterative returns F(0) to F(n) with the loop of the examples
+func iterative(n int) []*big.Int {
+	result := []*big.Int{big.NewInt(0), big.NewInt(1)}
+	for i := 2; i <= n; i++ {
+		result = append(result, new(big.Int).Add(result[i-1], result[i-2]))
+	}
+	return result[:n+1]
+}
+
+func TestBig(t *testing.T) {
+	want := iterative(300)
+	for n, w := range want {
+		if got := Big(n); got.Cmp(w) != 0 {
+			t.Errorf("Big(%v) = %v, want %v", n, got, w)
+		}
+		// F(-n) = (-1)^(n+1) * F(n)
+		wantNeg := new(big.Int).Set(w)
+		if n%2 == 0 {
+			wantNeg.Neg(wantNeg)
+		}
+		if got := Big(-n); got.Cmp(wantNeg) != 0 {
+			t.Errorf("Big(%v) = %v, want %v", -n, got, wantNeg)
+		}
+	}
+}
+
+func TestBigKnown(t *testing.T) {
+	tests := map[int]string{
+		-8:  "-21",
+		-7:  "13",
+		-1:  "1",
+		10:  "55",
+		92:  "7540113804746346429",
+		93:  "12200160415121876738",
+		100: "354224848179261915075",
+	}
+	for n, want := range tests {
+		if got := Big(n).String(); got != want {
+			t.Errorf("Big(%v) = %v, want %v", n, got, want)
+		}
+	}
+}
+
+func TestInt(t *testing.T) {
+	if strconv.IntSize != 64 {
+		t.Skip("the overflow is only tested for 64 bit ints")
+	}
+	got, err := Int(92)
+	if err != nil || got != 7540113804746346429 {
+		t.Errorf("Int(92) = %v, %v, want 7540113804746346429, nil", got, err)
+	}
+	for _, n := range []int{93, 94, 1000, -94} {
+		if got, err := Int(n); !errors.Is(err, ErrOverflow) {
+			t.Errorf("Int(%v) = %v, %v, want %v", n, got, err, ErrOverflow)
+		}
+	}
+	// F(-n) is ±F(n), so F(-92) is negative and fits, but F(-93) is as large as F(93) and overflows
+	if got, err := Int(-92); err != nil || got != -7540113804746346429 {
+		t.Errorf("Int(-92) = %v, %v, want -7540113804746346429, nil", got, err)
+	}
+	if got, err := Int(-93); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Int(-93) = %v, %v, want %v", got, err, ErrOverflow)
+	}
+}
+
+func TestSeqAndChan(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	want := iterative(99)
+	i := 0
+	for f := range Seq(100) {
+		if f.Cmp(want[i]) != 0 {
+			t.Errorf("Seq: value %v = %v, want %v", i, f, want[i])
+		}
+		i++
+	}
+	if i != 100 {
+		t.Errorf("Seq(100) returned %v values", i)
+	}
+
+	i = 0
+	for f := range Chan(context.Background(), 100) {
+		if f.Cmp(want[i]) != 0 {
+			t.Errorf("Chan: value %v = %v, want %v", i, f, want[i])
+		}
+		i++
+	}
+	if i != 100 {
+		t.Errorf("Chan(100) sent %v values", i)
+	}
+
+	// An infinite channel stops when the context is cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	c := Chan(ctx, -1)
+	<-c
+	<-c
+	cancel()
+}
@@ -3,8 +3,13 @@ package main
 import (
 	"fmt"
 	"math"
+	"math/big"
 	"runtime"
 	"time"
+
+	"github.com/philippgille/hello-go/clock"
+	"github.com/philippgille/hello-go/nthroot"
+	"github.com/philippgille/hello-go/solve"
 )
 
 // Basic for loop
@@ -69,6 +74,60 @@ func MySqrt(x float64) float64 {
 	return z
 }
 
+// MySqrt2 is MySqrt with the "solve" package, which stops after a maximum number of iterations.
+// Newton's method only runs on the mantissa of x, between 0.5 and 2, so starting at 1 needs only a few iterations even for large x,
+// and z*z can't overflow. Half of the exponent is added back to the root.
+// Negative numbers have no real root, so the error is nthroot.ErrNegative for them.
+// 0, -0, +Inf and NaN are their own roots, like with math.Sqrt.
+func MySqrt2(x float64) (float64, error) {
+	switch {
+	case x < 0:
+		return math.NaN(), nthroot.ErrNegative
+	case x == 0 || math.IsInf(x, 1) || math.IsNaN(x):
+		return x, nil // They have no mantissa for Newton's method
+	}
+	frac, exp := math.Frexp(x) // x = frac * 2^exp
+	if exp%2 != 0 {
+		frac *= 2
+		exp--
+	}
+	res, err := solve.Newton(
+		func(z float64) float64 { return z*z - frac },
+		func(z float64) float64 { return 2 * z },
+		1, solve.Options{},
+	)
+	return math.Ldexp(res.Root, exp/2), err
+}
+
+// Switch evaluation order
+// The clock is passed in, so the function can be tested with a clock.Fake on any day
+func whenIsSaturday(c clock.Clock) string {
+	today := c.Now().Weekday()
+	switch time.Saturday {
+	case today + 0:
+		return "Today."
+	case today + 1:
+		return "Tomorrow."
+	case today + 2:
+		return "In two days."
+	default:
+		return "Too far away."
+	}
+}
+
+// Switch without condition as alternative to long if-else statements
+func greeting(c clock.Clock) string {
+	t := c.Now()
+	switch {
+	case t.Hour() < 12:
+		return "Good morning!"
+	case t.Hour() < 17:
+		return "Good afternoon."
+	default:
+		return "Good evening."
+	}
+}
+
 // Defer statements get executed after a function returns
 func myDefer() {
 	defer fmt.Println("world")
@@ -94,6 +153,11 @@ func main() {
 	myFor2()
 
 	fmt.Println(sqrt(2), sqrt(-4))
+	// The "nthroot" package returns complex numbers instead of strings with "i", or an error in its real mode
+	fmt.Println(nthroot.ComplexSqrt(2), nthroot.ComplexSqrt(-4)) // (1.4142135623730951+0i) (0+2i)
+	fmt.Println(nthroot.Sqrt(-4))                                // NaN nthroot: even root of negative number
+	fmt.Println(nthroot.Root(-8, 3))                             // -2 <nil>
+	fmt.Println(nthroot.ComplexRoots(-8, 3))                     // [(1.0000000000000002+1.7320508075688772i) (-2+0i) (1-1.7320508075688772i)]
 
 	fmt.Println(
 		pow(3, 2, 10),
@@ -106,6 +170,15 @@ func main() {
 	)
 
 	fmt.Println(MySqrt(10000))
+	fmt.Println(MySqrt2(10000))
+	fmt.Println(MySqrt2(-1)) // NaN nthroot: even root of negative number, instead of looping forever like MySqrt(-1)
+	// More precision than float64 with math/big
+	root2, _ := nthroot.BigSqrt(big.NewFloat(2), 200)
+	fmt.Println(root2.Text('g', 60))                  // 1.41421356237309504880168872420969807856967187537694807317668
+	fmt.Println(nthroot.IntSqrt(big.NewInt(1 << 40))) // 1048576 true <nil>
+	// Brent's method finds roots of any function with a sign change in the interval, like cos(x) at π/2
+	res, _ := solve.Brent(math.Cos, 0, 3, solve.Options{})
+	fmt.Printf("%+v\n", res) // {Root:1.5707963267948966 Iterations:7 Converged:true Estimate:3.930189507173054e-13}
 
 	// Switch statement
 	fmt.Print("Go runs on ")
@@ -120,31 +193,10 @@ func main() {
 		fmt.Printf("%s.\n", os)
 	}
 
-	// Switch evaluation order
-
 	fmt.Println("When's Saturday?")
-	today := time.Now().Weekday()
-	switch time.Saturday {
-	case today + 0:
-		fmt.Println("Today.")
-	case today + 1:
-		fmt.Println("Tomorrow.")
-	case today + 2:
-		fmt.Println("In two days.")
-	default:
-		fmt.Println("Too far away.")
-	}
+	fmt.Println(whenIsSaturday(clock.Real))
 
-	// Switch without condition as alternative to long if-else statements
-	t := time.Now()
-	switch {
-	case t.Hour() < 12:
-		fmt.Println("Good morning!")
-	case t.Hour() < 17:
-		fmt.Println("Good afternoon.")
-	default:
-		fmt.Println("Good evening.")
-	}
+	fmt.Println(greeting(clock.Real))
 
 	myDefer()
 
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/philippgille/hello-go/clock"
+	"github.com/philippgille/hello-go/nthroot"
+)
+
+func TestWhenIsSaturday(t *testing.T) {
+	want := map[time.Weekday]string{
+		time.Sunday:    "Too far away.",
+		time.Monday:    "Too far away.",
+		time.Tuesday:   "Too far away.",
+		time.Wednesday: "Too far away.",
+		time.Thursday:  "In two days.",
+		time.Friday:    "Tomorrow.",
+		time.Saturday:  "Today.",
+	}
+	// January 7th 2024 is a Sunday
+	for day := 7; day < 14; day++ {
+		now := time.Date(2024, time.January, day, 12, 0, 0, 0, time.UTC)
+		if got := whenIsSaturday(clock.NewFake(now)); got != want[now.Weekday()] {
+			t.Errorf("whenIsSaturday() on %v = %q, want %q", now.Weekday(), got, want[now.Weekday()])
+		}
+	}
+}
+
+func TestGreeting(t *testing.T) {
+	tests := []struct {
+		hour, minute int
+		want         string
+	}{
+		{0, 0, "Good morning!"},
+		{11, 59, "Good morning!"},
+		{12, 0, "Good afternoon."},
+		{16, 59, "Good afternoon."},
+		{17, 0, "Good evening."},
+		{23, 59, "Good evening."},
+	}
+	for _, test := range tests {
+		now := time.Date(2024, time.January, 1, test.hour, test.minute, 0, 0, time.UTC)
+		if got := greeting(clock.NewFake(now)); got != test.want {
+			t.Errorf("greeting() at %02d:%02d = %q, want %q", test.hour, test.minute, got, test.want)
+		}
+	}
+}
+
+func TestMySqrt2(t *testing.T) {
+	for _, x := range []float64{1, 2, 10000, 1e-300, 1e300, math.MaxFloat64, math.SmallestNonzeroFloat64} {
+		got, err := MySqrt2(x)
+		if want := math.Sqrt(x); err != nil || math.Abs(got-want) > 1e-15*want {
+			t.Errorf("MySqrt2(%v) = %v, %v, want %v", x, got, err, want)
+		}
+	}
+	// Special values are returned unchanged, including the sign of -0
+	for _, x := range []float64{0, math.Copysign(0, -1), math.Inf(1), math.NaN()} {
+		got, err := MySqrt2(x)
+		if err != nil || math.Float64bits(got) != math.Float64bits(x) {
+			t.Errorf("MySqrt2(%v) = %v, %v, want %v", x, got, err, x)
+		}
+	}
+	if got := MySqrt(10000); got != 100 {
+		t.Errorf("MySqrt(10000) = %v, want 100", got)
+	}
+	for _, x := range []float64{-1, math.Inf(-1)} {
+		if got, err := MySqrt2(x); !errors.Is(err, nthroot.ErrNegative) || !math.IsNaN(got) {
+			t.Errorf("MySqrt2(%v) = %v, %v, want NaN, %v", x, got, err, nthroot.ErrNegative)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// Package future provides typed results of goroutines.
+// Instead of receiving from a shared channel (like "x, y := <-c, <-c" in "myChannel"),
+// each computation gets its own Future, so it's clear which result belongs to which computation.
+package future
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Future is the result of a computation that runs in its own goroutine.
+type Future[T any] struct {
+	done  chan struct{} // Closed when value and err are set
+	value T
+	err   error
+}
+
+// Go runs f in a new goroutine and returns its Future.
+// A panic in f is turned into an error.
+func Go[T any](f func() (T, error)) *Future[T] {
+	fut := &Future[T]{done: make(chan struct{})}
+	go func() {
+		defer close(fut.done)
+		defer func() {
+			if r := recover(); r != nil {
+				fut.err = fmt.Errorf("future: panic: %v", r)
+			}
+		}()
+		fut.value, fut.err = f()
+	}()
+	return fut
+}
+
+// Done returns a channel that's closed when the result is available.
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Await blocks until the result is available or ctx is done.
+// It can be called multiple times and from multiple goroutines.
+func (f *Future[T]) Await(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// Then returns a Future with the result of g, which is called with the value of f once it's available.
+// If f fails, g isn't called and the returned Future fails with the same error.
+func Then[T, U any](f *Future[T], g func(T) (U, error)) *Future[U] {
+	return Go(func() (U, error) {
+		<-f.done
+		if f.err != nil {
+			var zero U
+			return zero, f.err
+		}
+		return g(f.value)
+	})
+}
+
+// ========
+
+// All waits for all futures and returns their values in the same order.
+// It returns early with the first error that occurs.
+func All[T any](ctx context.Context, futures ...*Future[T]) ([]T, error) {
+	values := make([]T, len(futures))
+	pending := append([]*Future[T](nil), futures...)
+	for range futures {
+		i, err := next(ctx, pending)
+		if err != nil {
+			return nil, err
+		}
+		if pending[i].err != nil {
+			return nil, pending[i].err
+		}
+		values[i] = pending[i].value
+		pending[i] = nil
+	}
+	return values, nil
+}
+
+// Any returns the value of the first future that succeeds.
+// If all of them fail, the errors are joined.
+func Any[T any](ctx context.Context, futures ...*Future[T]) (T, error) {
+	var zero T
+	if len(futures) == 0 {
+		return zero, errNoFutures
+	}
+	var errs []error
+	pending := append([]*Future[T](nil), futures...)
+	for range futures {
+		i, err := next(ctx, pending)
+		if err != nil {
+			return zero, err
+		}
+		if pending[i].err == nil {
+			return pending[i].value, nil
+		}
+		errs = append(errs, pending[i].err)
+		pending[i] = nil
+	}
+	return zero, errors.Join(errs...)
+}
+
+// First returns the result of the first future that's done, no matter if it succeeded or failed.
+func First[T any](ctx context.Context, futures ...*Future[T]) (T, error) {
+	var zero T
+	if len(futures) == 0 {
+		return zero, errNoFutures
+	}
+	i, err := next(ctx, futures)
+	if err != nil {
+		return zero, err
+	}
+	return futures[i].value, futures[i].err
+}
+
+var errNoFutures = errors.New("future: no futures")
+
+// next blocks until one of the non-nil futures is done and returns its index
+func next[T any](ctx context.Context, futures []*Future[T]) (int, error) {
+	// The number of futures is only known at runtime, so a select statement can't be used
+	cases := []reflect.SelectCase{{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())}}
+	indexes := []int{-1}
+	for i, f := range futures {
+		if f != nil {
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(f.done)})
+			indexes = append(indexes, i)
+		}
+	}
+	chosen, _, _ := reflect.Select(cases)
+	if chosen == 0 {
+		return -1, ctx.Err()
+	}
+	return indexes[chosen], nil
+}
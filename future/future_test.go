@@ -0,0 +1,175 @@
+package future
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/philippgille/hello-go/leakcheck"
+)
+
+var (
+	errA = errors.New("a")
+	errB = errors.New("b")
+)
+
+// after returns a Future with the value or error after d
+func after[T any](d time.Duration, v T, err error) *Future[T] {
+	return Go(func() (T, error) {
+		time.Sleep(d)
+		return v, err
+	})
+}
+
+// blocked returns a Future that's done when release is closed
+func blocked(release <-chan struct{}) *Future[int] {
+	return Go(func() (int, error) {
+		<-release
+		return -1, nil
+	})
+}
+
+func TestAwait(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	f := after(time.Millisecond, 42, nil)
+	for i := 0; i < 2; i++ {
+		if v, err := f.Await(context.Background()); v != 42 || err != nil {
+			t.Errorf("Await() = %v, %v, want 42, nil", v, err)
+		}
+	}
+	select {
+	case <-f.Done():
+	default:
+		t.Error("Done() isn't closed after Await")
+	}
+}
+
+func TestAwaitContext(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	release := make(chan struct{})
+	defer close(release)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := blocked(release).Await(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Await() = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestPanic(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	f := Go(func() (int, error) {
+		panic("boom")
+	})
+	if _, err := f.Await(context.Background()); err == nil || !strings.Contains(err.Error(), "panic: boom") {
+		t.Errorf("Await() error = %v, want the panic", err)
+	}
+}
+
+func TestThen(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	double := func(v int) (int, error) { return 2 * v, nil }
+	f := Then(Then(after(time.Millisecond, 1, nil), double), double)
+	if v, err := f.Await(context.Background()); v != 4 || err != nil {
+		t.Errorf("Await() = %v, %v, want 4, nil", v, err)
+	}
+
+	// Errors skip the following functions
+	called := false
+	f = Then(Then(after(0, 1, errA), double), func(v int) (int, error) {
+		called = true
+		return v, nil
+	})
+	if _, err := f.Await(context.Background()); !errors.Is(err, errA) {
+		t.Errorf("Await() error = %v, want %v", err, errA)
+	}
+	if called {
+		t.Error("function was called after an error")
+	}
+
+	// Errors and panics of the function itself
+	if _, err := Then(after(0, 1, nil), func(int) (int, error) { return 0, errB }).Await(context.Background()); !errors.Is(err, errB) {
+		t.Errorf("Await() error = %v, want %v", err, errB)
+	}
+	if _, err := Then(after(0, 1, nil), func(int) (int, error) { panic("boom") }).Await(context.Background()); err == nil {
+		t.Error("Await() error = nil, want the panic")
+	}
+}
+
+func TestAll(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	// The values are in the order of the futures, not in the order they're done
+	values, err := All(context.Background(),
+		after(30*time.Millisecond, 1, nil),
+		after(20*time.Millisecond, 2, nil),
+		after(10*time.Millisecond, 3, nil),
+	)
+	if err != nil || !slices.Equal(values, []int{1, 2, 3}) {
+		t.Errorf("All() = %v, %v, want [1 2 3], nil", values, err)
+	}
+	if values, err := All[int](context.Background()); err != nil || len(values) != 0 {
+		t.Errorf("All() without futures = %v, %v, want [], nil", values, err)
+	}
+}
+
+// All returns as soon as one future fails, without waiting for the others
+func TestAllEarlyError(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	release := make(chan struct{})
+	defer close(release)
+	values, err := All(context.Background(), blocked(release), after(time.Millisecond, 0, errA))
+	if !errors.Is(err, errA) || values != nil {
+		t.Errorf("All() = %v, %v, want nil, %v", values, err, errA)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := All(ctx, blocked(release)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("All() = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestAny(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	// The failure that's done first is ignored
+	v, err := Any(context.Background(), after(10*time.Millisecond, 1, nil), after(0, 2, errA))
+	if v != 1 || err != nil {
+		t.Errorf("Any() = %v, %v, want 1, nil", v, err)
+	}
+
+	// All errors are joined
+	_, err = Any(context.Background(), after(10*time.Millisecond, 1, errA), after(0, 2, errB))
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Errorf("Any() error = %v, want both errors", err)
+	}
+
+	if _, err := Any[int](context.Background()); err == nil {
+		t.Error("Any() without futures = nil error")
+	}
+}
+
+func TestFirst(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	release := make(chan struct{})
+	defer close(release)
+	if v, err := First(context.Background(), blocked(release), after(0, 2, nil)); v != 2 || err != nil {
+		t.Errorf("First() = %v, %v, want 2, nil", v, err)
+	}
+	// Also if it failed
+	if _, err := First(context.Background(), blocked(release), after(0, 2, errA)); !errors.Is(err, errA) {
+		t.Errorf("First() error = %v, want %v", err, errA)
+	}
+	if _, err := First[int](context.Background()); err == nil {
+		t.Error("First() without futures = nil error")
+	}
+}
@@ -0,0 +1,142 @@
+// Package geo calculates distances, bearings and positions on the earth,
+// for locations like the "vertex2{Lat, Long}" values in the moretypes chapter.
+// All angles are in degrees and all distances in meters.
+package geo
+
+import (
+	"errors"
+	"math"
+)
+
+// EarthRadius is the mean radius of the earth in meters, used by the spherical calculations.
+const EarthRadius = 6371008.8
+
+// WGS84 ellipsoid, used by Vincenty
+const (
+	wgs84A = 6378137.0         // Semi-major axis
+	wgs84F = 1 / 298.257223563 // Flattening
+	wgs84B = (1 - wgs84F) * wgs84A
+)
+
+// ErrNoConvergence is returned by Vincenty for (nearly) antipodal points, for which the formula doesn't converge.
+var ErrNoConvergence = errors.New("geo: Vincenty formula failed to converge")
+
+// Location is a position on the earth.
+type Location struct {
+	Lat, Long float64
+}
+
+// Haversine returns the great-circle distance between a and b on a spherical earth.
+// It's accurate to about 0.5%.
+func Haversine(a, b Location) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLat := lat2 - lat1
+	dLong := radians(b.Long - a.Long)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLong/2)*math.Sin(dLong/2)
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Vincenty returns the distance between a and b on the WGS84 ellipsoid,
+// which is accurate to less than a millimeter.
+// It returns ErrNoConvergence for (nearly) antipodal points.
+func Vincenty(a, b Location) (float64, error) {
+	L := radians(b.Long - a.Long)
+	U1 := math.Atan((1 - wgs84F) * math.Tan(radians(a.Lat)))
+	U2 := math.Atan((1 - wgs84F) * math.Tan(radians(b.Lat)))
+	sinU1, cosU1 := math.Sincos(U1)
+	sinU2, cosU2 := math.Sincos(U2)
+
+	lambda := L
+	var sinSigma, cosSigma, sigma, cosSqAlpha, cos2SigmaM float64
+	for i := 0; ; i++ {
+		if i == 200 {
+			return 0, ErrNoConvergence
+		}
+		sinLambda, cosLambda := math.Sincos(lambda)
+		sinSigma = math.Hypot(cosU2*sinLambda, cosU1*sinU2-sinU1*cosU2*cosLambda)
+		if sinSigma == 0 {
+			return 0, nil // Same point
+		}
+		cosSigma = sinU1*sinU2 + cosU1*cosU2*cosLambda
+		sigma = math.Atan2(sinSigma, cosSigma)
+		sinAlpha := cosU1 * cosU2 * sinLambda / sinSigma
+		cosSqAlpha = 1 - sinAlpha*sinAlpha
+		cos2SigmaM = 0.0 // On the equator
+		if cosSqAlpha != 0 {
+			cos2SigmaM = cosSigma - 2*sinU1*sinU2/cosSqAlpha
+		}
+		C := wgs84F / 16 * cosSqAlpha * (4 + wgs84F*(4-3*cosSqAlpha))
+		old := lambda
+		lambda = L + (1-C)*wgs84F*sinAlpha*(sigma+C*sinSigma*(cos2SigmaM+C*cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)))
+		if math.Abs(lambda-old) < 1e-12 {
+			break
+		}
+	}
+
+	uSq := cosSqAlpha * (wgs84A*wgs84A - wgs84B*wgs84B) / (wgs84B * wgs84B)
+	A := 1 + uSq/16384*(4096+uSq*(-768+uSq*(320-175*uSq)))
+	B := uSq / 1024 * (256 + uSq*(-128+uSq*(74-47*uSq)))
+	deltaSigma := B * sinSigma * (cos2SigmaM + B/4*(cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)-
+		B/6*cos2SigmaM*(-3+4*sinSigma*sinSigma)*(-3+4*cos2SigmaM*cos2SigmaM)))
+	return wgs84B * A * (sigma - deltaSigma), nil
+}
+
+// Bearing returns the initial bearing (forward azimuth) when going from a to b on a great circle,
+// between 0 (north) and 360, clockwise.
+func Bearing(a, b Location) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLong := radians(b.Long - a.Long)
+	y := math.Sin(dLong) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLong)
+	return math.Mod(degrees(math.Atan2(y, x))+360, 360)
+}
+
+// Midpoint returns the point halfway between a and b on a great circle.
+func Midpoint(a, b Location) Location {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	long1 := radians(a.Long)
+	dLong := radians(b.Long - a.Long)
+	bx := math.Cos(lat2) * math.Cos(dLong)
+	by := math.Cos(lat2) * math.Sin(dLong)
+	lat := math.Atan2(math.Sin(lat1)+math.Sin(lat2), math.Hypot(math.Cos(lat1)+bx, by))
+	long := long1 + math.Atan2(by, math.Cos(lat1)+bx)
+	return Location{degrees(lat), normalizeLong(degrees(long))}
+}
+
+// Destination returns the point that's reached when going the distance from a with the initial bearing on a great circle.
+func Destination(a Location, bearing, distance float64) Location {
+	lat1, long1 := radians(a.Lat), radians(a.Long)
+	theta := radians(bearing)
+	delta := distance / EarthRadius
+	lat := math.Asin(math.Sin(lat1)*math.Cos(delta) + math.Cos(lat1)*math.Sin(delta)*math.Cos(theta))
+	long := long1 + math.Atan2(math.Sin(theta)*math.Sin(delta)*math.Cos(lat1), math.Cos(delta)-math.Sin(lat1)*math.Sin(lat))
+	return Location{degrees(lat), normalizeLong(degrees(long))}
+}
+
+// Nearest returns the name of the place that's closest to l, and its Haversine distance.
+// ok is false if there are no places.
+func Nearest(l Location, places map[string]Location) (name string, distance float64, ok bool) {
+	for n, p := range places {
+		d := Haversine(l, p)
+		// Compare names on equal distance, so the result doesn't depend on the random map order
+		if !ok || d < distance || (d == distance && n < name) {
+			name, distance, ok = n, d, true
+		}
+	}
+	return name, distance, ok
+}
+
+// ========
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func degrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
+
+// normalizeLong maps a longitude to [-180, 180)
+func normalizeLong(long float64) float64 {
+	return math.Mod(math.Mod(long+180, 360)+360, 360) - 180
+}
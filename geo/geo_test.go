@@ -0,0 +1,194 @@
+package geo
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// dms converts degrees, minutes and seconds to degrees
+func dms(d, m, s float64) float64 {
+	if d < 0 {
+		return d - m/60 - s/3600
+	}
+	return d + m/60 + s/3600
+}
+
+var (
+	// The example of Vincenty's paper and of Geoscience Australia
+	flindersPeak = Location{dms(-37, 57, 3.72030), dms(144, 25, 29.52440)}
+	buninyong    = Location{dms(-37, 39, 10.15610), dms(143, 55, 35.38390)}
+
+	berlin = Location{52.5200, 13.4050}
+	paris  = Location{48.8566, 2.3522}
+	london = Location{51.5074, -0.1278}
+)
+
+func TestVincenty(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Location
+		want float64 // Meters
+		tol  float64
+	}{
+		{"Flinders Peak to Buninyong", flindersPeak, buninyong, 54972.271, 0.001},
+		{"one degree on the equator", Location{0, 0}, Location{0, 1}, wgs84A * math.Pi / 180, 0.001},
+		{"equator to pole", Location{0, 0}, Location{90, 0}, 10001965.729, 0.001},
+		{"across the date line", Location{0, 179.5}, Location{0, -179.5}, wgs84A * math.Pi / 180, 0.001},
+		{"same point", berlin, berlin, 0, 0},
+	}
+	for _, test := range tests {
+		d, err := Vincenty(test.a, test.b)
+		if err != nil {
+			t.Errorf("%v: %v", test.name, err)
+			continue
+		}
+		if math.Abs(d-test.want) > test.tol {
+			t.Errorf("%v: Vincenty() = %.4f, want %.4f", test.name, d, test.want)
+		}
+		if back, _ := Vincenty(test.b, test.a); math.Abs(back-d) > 1e-6 {
+			t.Errorf("%v: Vincenty() backwards = %.4f, want %.4f", test.name, back, d)
+		}
+	}
+
+	if _, err := Vincenty(Location{0, 0}, Location{0.5, 179.7}); !errors.Is(err, ErrNoConvergence) {
+		t.Errorf("Vincenty() of nearly antipodal points = %v, want %v", err, ErrNoConvergence)
+	}
+}
+
+func TestHaversine(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Location
+		want float64
+	}{
+		{"one degree", Location{0, 0}, Location{0, 1}, EarthRadius * math.Pi / 180},
+		{"equator to pole", Location{0, 0}, Location{90, 0}, EarthRadius * math.Pi / 2},
+		{"antipodes", Location{0, 0}, Location{0, 180}, EarthRadius * math.Pi},
+		{"pole to pole", Location{90, 0}, Location{-90, 0}, EarthRadius * math.Pi},
+		{"same point", paris, paris, 0},
+	}
+	for _, test := range tests {
+		if d := Haversine(test.a, test.b); math.Abs(d-test.want) > 1e-6 {
+			t.Errorf("%v: Haversine() = %.4f, want %.4f", test.name, d, test.want)
+		}
+	}
+
+	// Within 0.5% of the ellipsoid
+	for _, pair := range [][2]Location{{flindersPeak, buninyong}, {berlin, paris}, {paris, london}, {london, berlin}} {
+		h := Haversine(pair[0], pair[1])
+		v, _ := Vincenty(pair[0], pair[1])
+		if math.Abs(h-v)/v > 0.005 {
+			t.Errorf("Haversine(%v, %v) = %.0f, Vincenty = %.0f", pair[0], pair[1], h, v)
+		}
+	}
+	// Berlin to Paris is about 878 km
+	if d := Haversine(berlin, paris); math.Abs(d-878e3) > 2e3 {
+		t.Errorf("Haversine(Berlin, Paris) = %.0f, want about 878 km", d)
+	}
+}
+
+func TestBearingDestination(t *testing.T) {
+	tests := []struct {
+		a, b Location
+		want float64
+	}{
+		{Location{0, 0}, Location{10, 0}, 0},
+		{Location{0, 0}, Location{0, 10}, 90},
+		{Location{10, 0}, Location{0, 0}, 180},
+		{Location{0, 0}, Location{0, -10}, 270},
+	}
+	for _, test := range tests {
+		if b := Bearing(test.a, test.b); math.Abs(b-test.want) > 1e-9 {
+			t.Errorf("Bearing(%v, %v) = %v, want %v", test.a, test.b, b, test.want)
+		}
+	}
+
+	// Going from Berlin towards Paris for the whole distance arrives in Paris
+	dest := Destination(berlin, Bearing(berlin, paris), Haversine(berlin, paris))
+	if d := Haversine(dest, paris); d > 0.01 {
+		t.Errorf("Destination() = %v, %.3f m from Paris", dest, d)
+	}
+	// And going half of it arrives at the midpoint
+	mid := Midpoint(berlin, paris)
+	half := Destination(berlin, Bearing(berlin, paris), Haversine(berlin, paris)/2)
+	if d := Haversine(mid, half); d > 0.01 {
+		t.Errorf("Midpoint() = %v, but half way is %v", mid, half)
+	}
+	// Crossing the date line keeps the longitude in range
+	if l := Destination(Location{0, 179}, 90, Haversine(Location{0, 0}, Location{0, 2})); math.Abs(l.Long-(-179)) > 1e-9 {
+		t.Errorf("Destination() across the date line = %v, want longitude -179", l)
+	}
+}
+
+func TestNearest(t *testing.T) {
+	if _, _, ok := Nearest(berlin, nil); ok {
+		t.Error("Nearest() without places is ok")
+	}
+	places := map[string]Location{"Paris": paris, "London": london, "Berlin": berlin}
+	if name, d, ok := Nearest(Location{51, 1}, places); !ok || name != "London" || d != Haversine(Location{51, 1}, london) {
+		t.Errorf("Nearest() = %v, %v, %v, want London", name, d, ok)
+	}
+	// Equal distances are decided by the name
+	for i := 0; i < 10; i++ {
+		if name, _, _ := Nearest(Location{0, 0}, map[string]Location{"b": {0, 1}, "a": {0, -1}, "c": {1, 0}}); name != "a" {
+			t.Fatalf("Nearest() = %v, want a", name)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	data, err := json.Marshal(paris)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Longitude first
+	if want := `{"type":"Point","coordinates":[2.3522,48.8566]}`; string(data) != want {
+		t.Errorf("Marshal() = %s, want %s", data, want)
+	}
+	var l Location
+	if err := json.Unmarshal([]byte(`{"type":"Point","coordinates":[2.3522,48.8566,35]}`), &l); err != nil || l != paris {
+		t.Errorf("Unmarshal() with altitude = %v, %v, want %v", l, err, paris)
+	}
+	for _, invalid := range []string{`{"type":"LineString","coordinates":[1,2]}`, `{"type":"Point","coordinates":[1]}`, `[1,2]`} {
+		if err := json.Unmarshal([]byte(invalid), &l); err == nil {
+			t.Errorf("Unmarshal(%s) = nil error", invalid)
+		}
+	}
+}
+
+func TestFeatureCollection(t *testing.T) {
+	places := map[string]Location{"Paris": paris, "London": london, "Berlin": berlin, "Null Island": {}}
+	data, err := MarshalFeatureCollection(places)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Sorted by name
+	if b, l := strings.Index(string(data), "Berlin"), strings.Index(string(data), "London"); b < 0 || b > l {
+		t.Errorf("features aren't sorted by name: %s", data)
+	}
+	got, err := UnmarshalFeatureCollection(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, places) {
+		t.Errorf("round trip = %v, want %v", got, places)
+	}
+
+	if data, _ := MarshalFeatureCollection(nil); string(data) != `{"type":"FeatureCollection","features":[]}` {
+		t.Errorf("MarshalFeatureCollection(nil) = %s", data)
+	}
+	for _, invalid := range []string{
+		`{"type":"Feature"}`,
+		`{"type":"FeatureCollection","features":[{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]},"properties":{}}]}`,
+		`{"type":"FeatureCollection","features":[{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]},"properties":{"name":3}}]}`,
+		`{"type":"FeatureCollection","features":[{"type":"Feature","geometry":{"type":"Polygon","coordinates":[]},"properties":{"name":"x"}}]}`,
+	} {
+		if _, err := UnmarshalFeatureCollection([]byte(invalid)); err == nil {
+			t.Errorf("UnmarshalFeatureCollection(%s) = nil error", invalid)
+		}
+	}
+}
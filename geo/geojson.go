@@ -0,0 +1,103 @@
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// GeoJSON (RFC 7946) stores coordinates as [longitude, latitude], the other way around than Location.
+
+type geometry struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+type feature struct {
+	Type       string                 `json:"type"`
+	Geometry   geometry               `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type featureCollection struct {
+	Type     string    `json:"type"`
+	Features []feature `json:"features"`
+}
+
+// MarshalJSON encodes l as GeoJSON Point.
+func (l Location) MarshalJSON() ([]byte, error) {
+	return json.Marshal(point(l))
+}
+
+// UnmarshalJSON decodes a GeoJSON Point.
+func (l *Location) UnmarshalJSON(data []byte) error {
+	var g geometry
+	if err := json.Unmarshal(data, &g); err != nil {
+		return err
+	}
+	loc, err := fromPoint(g)
+	if err != nil {
+		return err
+	}
+	*l = loc
+	return nil
+}
+
+// MarshalFeatureCollection encodes the places as GeoJSON FeatureCollection with one Point feature per place.
+// The name is stored in the "name" property. Features are sorted by name.
+func MarshalFeatureCollection(places map[string]Location) ([]byte, error) {
+	names := make([]string, 0, len(places))
+	for name := range places {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fc := featureCollection{Type: "FeatureCollection", Features: []feature{}}
+	for _, name := range names {
+		fc.Features = append(fc.Features, feature{
+			Type:       "Feature",
+			Geometry:   point(places[name]),
+			Properties: map[string]interface{}{"name": name},
+		})
+	}
+	return json.Marshal(fc)
+}
+
+// UnmarshalFeatureCollection decodes a GeoJSON FeatureCollection of Point features with a "name" property.
+func UnmarshalFeatureCollection(data []byte) (map[string]Location, error) {
+	var fc featureCollection
+	if err := json.Unmarshal(data, &fc); err != nil {
+		return nil, err
+	}
+	if fc.Type != "FeatureCollection" {
+		return nil, fmt.Errorf("geo: expected FeatureCollection, got %q", fc.Type)
+	}
+	places := make(map[string]Location, len(fc.Features))
+	for i, f := range fc.Features {
+		name, ok := f.Properties["name"].(string)
+		if !ok {
+			return nil, fmt.Errorf("geo: feature %v has no \"name\" property", i)
+		}
+		loc, err := fromPoint(f.Geometry)
+		if err != nil {
+			return nil, fmt.Errorf("geo: feature %q: %v", name, err)
+		}
+		places[name] = loc
+	}
+	return places, nil
+}
+
+func point(l Location) geometry {
+	return geometry{"Point", []float64{l.Long, l.Lat}}
+}
+
+func fromPoint(g geometry) (Location, error) {
+	if g.Type != "Point" {
+		return Location{}, fmt.Errorf("geo: expected Point, got %q", g.Type)
+	}
+	// A third coordinate (altitude) is allowed, but ignored
+	if len(g.Coordinates) < 2 {
+		return Location{}, fmt.Errorf("geo: Point needs at least 2 coordinates, got %v", len(g.Coordinates))
+	}
+	return Location{Lat: g.Coordinates[1], Long: g.Coordinates[0]}, nil
+}
@@ -0,0 +1,43 @@
+// Package goid parses goroutine IDs and states from the headers of stack traces, like "goroutine 7 [chan send]:".
+// Go intentionally doesn't offer an API for goroutine IDs, but they're what makes traces and leak reports readable.
+// It's shared by the tracing and leakcheck packages.
+package goid
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Parse parses the header line of a stack trace.
+// The state can contain more details like "chan receive, 2 minutes", of which only the first part is returned.
+// ok is false if the stack trace doesn't start with a header.
+func Parse(stack string) (id uint64, state string, ok bool) {
+	header := stack
+	if i := strings.Index(stack, "\n"); i != -1 {
+		header = stack[:i]
+	}
+	if !strings.HasPrefix(header, "goroutine ") {
+		return 0, "", false
+	}
+	header = strings.TrimPrefix(header, "goroutine ")
+	i := strings.Index(header, " [")
+	j := strings.LastIndex(header, "]")
+	if i == -1 || j < i {
+		return 0, "", false
+	}
+	id, err := strconv.ParseUint(header[:i], 10, 64)
+	if err != nil {
+		return 0, "", false
+	}
+	state = strings.SplitN(header[i+2:j], ",", 2)[0]
+	return id, state, true
+}
+
+// Current returns the ID of the calling goroutine.
+func Current() uint64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	id, _, _ := Parse(string(buf))
+	return id
+}
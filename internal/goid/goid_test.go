@@ -0,0 +1,47 @@
+package goid
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		stack string
+		id    uint64
+		state string
+		ok    bool
+	}{
+		{"goroutine 7 [chan send]:\nmain.main()", 7, "chan send", true},
+		{"goroutine 123 [chan receive, 2 minutes]:\nmain.f()", 123, "chan receive", true},
+		{"goroutine 1 [running]:", 1, "running", true},
+		{"goroutine 18446744073709551615 [select]:", 18446744073709551615, "select", true},
+		{"goroutine x [running]:", 0, "", false},
+		{"goroutine -1 [running]:", 0, "", false},
+		{"goroutine 1 running:", 0, "", false},
+		{"main.main()\ngoroutine 1 [running]:", 0, "", false},
+		{"", 0, "", false},
+	}
+	for _, test := range tests {
+		id, state, ok := Parse(test.stack)
+		if id != test.id || state != test.state || ok != test.ok {
+			t.Errorf("Parse(%q) = %v, %q, %v, want %v, %q, %v", test.stack, id, state, ok, test.id, test.state, test.ok)
+		}
+	}
+}
+
+func TestCurrent(t *testing.T) {
+	main := Current()
+	if main == 0 {
+		t.Fatal("Current() = 0")
+	}
+	if Current() != main {
+		t.Error("Current() changed within the same goroutine")
+	}
+	other := make(chan uint64)
+	go func() {
+		other <- Current()
+	}()
+	if id := <-other; id == 0 || id == main {
+		t.Errorf("Current() in another goroutine = %v, main goroutine %v", id, main)
+	}
+}
@@ -0,0 +1,417 @@
+// Package ipaddr parses and formats IPv4 and IPv6 addresses and CIDR prefixes,
+// like the "ipAddr [4]byte" type in the methods chapter, but for both IP versions.
+package ipaddr
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"strconv"
+	"strings"
+)
+
+// Addr is an IPv4 or IPv6 address.
+// It's comparable, so it can be used with == and as map key.
+// The zero value is not a valid address.
+type Addr struct {
+	b   [16]byte // IPv4 addresses are stored as IPv4-mapped IPv6 addresses
+	ver int      // 0 for invalid, 4 or 6
+}
+
+// AddrFrom4 returns the IPv4 address of the bytes.
+func AddrFrom4(b [4]byte) Addr {
+	a := Addr{ver: 4}
+	a.b[10], a.b[11] = 0xff, 0xff
+	copy(a.b[12:], b[:])
+	return a
+}
+
+// AddrFrom16 returns the IPv6 address of the bytes.
+// IPv4-mapped addresses (::ffff:1.2.3.4) stay IPv6 addresses, use Unmap to convert them to IPv4.
+func AddrFrom16(b [16]byte) Addr {
+	return Addr{b: b, ver: 6}
+}
+
+// ParseAddr parses an IPv4 address like "192.168.0.1" or an IPv6 address like "2001:db8::1" or "::ffff:192.168.0.1".
+func ParseAddr(s string) (Addr, error) {
+	if strings.Contains(s, ":") {
+		return parse6(s)
+	}
+	b, err := parse4(s)
+	if err != nil {
+		return Addr{}, err
+	}
+	return AddrFrom4(b), nil
+}
+
+// MustParseAddr is like ParseAddr, but panics on invalid input. It's meant for constants.
+func MustParseAddr(s string) Addr {
+	a, err := ParseAddr(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+func parse4(s string) ([4]byte, error) {
+	var b [4]byte
+	parts := strings.Split(s, ".")
+	if len(parts) != 4 {
+		return b, fmt.Errorf("ipaddr: invalid IPv4 address %q", s)
+	}
+	for i, part := range parts {
+		// Leading zeros are rejected, because some parsers interpret them as octal numbers
+		if part == "" || len(part) > 3 || (len(part) > 1 && part[0] == '0') {
+			return b, fmt.Errorf("ipaddr: invalid IPv4 address %q", s)
+		}
+		v, err := strconv.ParseUint(part, 10, 8)
+		if err != nil {
+			return b, fmt.Errorf("ipaddr: invalid IPv4 address %q", s)
+		}
+		b[i] = byte(v)
+	}
+	return b, nil
+}
+
+func parse6(s string) (Addr, error) {
+	invalid := fmt.Errorf("ipaddr: invalid IPv6 address %q", s)
+	var b [16]byte
+
+	// Split at "::", which replaces one or more groups of zeros
+	head, tail, compressed := strings.Cut(s, "::")
+	if compressed && strings.Contains(tail, "::") {
+		return Addr{}, invalid
+	}
+	parseGroups := func(s string, allowIPv4 bool) ([]byte, error) {
+		var result []byte
+		if s == "" {
+			return result, nil
+		}
+		groups := strings.Split(s, ":")
+		for i, g := range groups {
+			// The last 32 bits can be written as IPv4 address
+			if allowIPv4 && i == len(groups)-1 && strings.Contains(g, ".") {
+				b4, err := parse4(g)
+				if err != nil {
+					return nil, invalid
+				}
+				return append(result, b4[:]...), nil
+			}
+			if g == "" || len(g) > 4 {
+				return nil, invalid
+			}
+			v, err := strconv.ParseUint(g, 16, 16)
+			if err != nil {
+				return nil, invalid
+			}
+			result = append(result, byte(v>>8), byte(v))
+		}
+		return result, nil
+	}
+
+	front, err := parseGroups(head, !compressed)
+	if err != nil {
+		return Addr{}, err
+	}
+	back, err := parseGroups(tail, true)
+	if err != nil {
+		return Addr{}, err
+	}
+	n := len(front) + len(back)
+	if (!compressed && n != 16) || (compressed && n > 14) {
+		return Addr{}, invalid
+	}
+	copy(b[:], front)
+	copy(b[16-len(back):], back)
+	return AddrFrom16(b), nil
+}
+
+// IsValid reports whether a is a parsed or constructed address, as opposed to the zero value.
+func (a Addr) IsValid() bool {
+	return a.ver != 0
+}
+
+// Is4 reports whether a is an IPv4 address.
+func (a Addr) Is4() bool {
+	return a.ver == 4
+}
+
+// Is6 reports whether a is an IPv6 address, including IPv4-mapped ones.
+func (a Addr) Is6() bool {
+	return a.ver == 6
+}
+
+// Is4In6 reports whether a is an IPv4-mapped IPv6 address like ::ffff:1.2.3.4.
+func (a Addr) Is4In6() bool {
+	return a.ver == 6 && isMapped(a.b)
+}
+
+// Unmap converts an IPv4-mapped IPv6 address to IPv4 and returns other addresses unchanged.
+func (a Addr) Unmap() Addr {
+	if a.Is4In6() {
+		a.ver = 4
+	}
+	return a
+}
+
+// BitLen returns 32 for IPv4 and 128 for IPv6 addresses.
+func (a Addr) BitLen() int {
+	switch a.ver {
+	case 4:
+		return 32
+	case 6:
+		return 128
+	}
+	return 0
+}
+
+// As4 returns the bytes of an IPv4 (or IPv4-mapped) address.
+func (a Addr) As4() [4]byte {
+	var b [4]byte
+	copy(b[:], a.b[12:])
+	return b
+}
+
+// As16 returns the bytes of the address in its IPv6 form.
+func (a Addr) As16() [16]byte {
+	return a.b
+}
+
+// Compare returns -1, 0 or 1 depending on whether a is less than, equal to or greater than o.
+// IPv4 addresses are less than IPv6 addresses.
+func (a Addr) Compare(o Addr) int {
+	if a.ver != o.ver {
+		if a.ver < o.ver {
+			return -1
+		}
+		return 1
+	}
+	for i := range a.b {
+		if a.b[i] != o.b[i] {
+			if a.b[i] < o.b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// Next returns the following address.
+// ok is false if a is the last address of its version.
+func (a Addr) Next() (next Addr, ok bool) {
+	start := 16 - a.BitLen()/8
+	for i := 15; i >= start; i-- {
+		a.b[i]++
+		if a.b[i] != 0 {
+			return a, true
+		}
+	}
+	return Addr{}, false
+}
+
+// Prev returns the preceding address.
+// ok is false if a is the first address of its version.
+func (a Addr) Prev() (prev Addr, ok bool) {
+	start := 16 - a.BitLen()/8
+	for i := 15; i >= start; i-- {
+		a.b[i]--
+		if a.b[i] != 0xff {
+			return a, true
+		}
+	}
+	return Addr{}, false
+}
+
+// String formats IPv4 addresses like "1.2.3.4" and IPv6 addresses according to RFC 5952,
+// like "2001:db8::1" and "::ffff:1.2.3.4".
+func (a Addr) String() string {
+	switch a.ver {
+	case 4:
+		return fmt.Sprintf("%v.%v.%v.%v", a.b[12], a.b[13], a.b[14], a.b[15])
+	case 6:
+		if isMapped(a.b) {
+			return "::ffff:" + a.Unmap().String()
+		}
+		return format6(a.b)
+	}
+	return "invalid IP"
+}
+
+func format6(b [16]byte) string {
+	var groups [8]uint16
+	for i := range groups {
+		groups[i] = uint16(b[2*i])<<8 | uint16(b[2*i+1])
+	}
+	// Find the longest run of at least 2 zero groups, the first one if there are multiple
+	bestStart, bestLen := -1, 1
+	for i := 0; i < 8; {
+		if groups[i] != 0 {
+			i++
+			continue
+		}
+		j := i
+		for j < 8 && groups[j] == 0 {
+			j++
+		}
+		if j-i > bestLen {
+			bestStart, bestLen = i, j-i
+		}
+		i = j
+	}
+
+	var sb strings.Builder
+	for i := 0; i < 8; i++ {
+		if i == bestStart {
+			sb.WriteString("::")
+			i += bestLen - 1
+			continue
+		}
+		if i > 0 && i != bestStart+bestLen {
+			sb.WriteByte(':')
+		}
+		sb.WriteString(strconv.FormatUint(uint64(groups[i]), 16))
+	}
+	return sb.String()
+}
+
+func isMapped(b [16]byte) bool {
+	for _, v := range b[:10] {
+		if v != 0 {
+			return false
+		}
+	}
+	return b[10] == 0xff && b[11] == 0xff
+}
+
+// ========
+
+// Prefix is an address with a prefix length, like "192.168.0.0/16" in CIDR notation.
+type Prefix struct {
+	addr Addr
+	bits int
+}
+
+// ErrInvalidPrefix is returned for prefix lengths that don't fit to the address.
+var ErrInvalidPrefix = errors.New("ipaddr: invalid prefix length")
+
+// PrefixFrom returns the prefix of the address with the given length.
+// The host bits of the address are kept, use Masked to clear them.
+func PrefixFrom(a Addr, bits int) (Prefix, error) {
+	if !a.IsValid() || bits < 0 || bits > a.BitLen() {
+		return Prefix{}, ErrInvalidPrefix
+	}
+	return Prefix{a, bits}, nil
+}
+
+// ParsePrefix parses a prefix in CIDR notation like "10.0.0.0/8" or "2001:db8::/32".
+func ParsePrefix(s string) (Prefix, error) {
+	addr, bits, ok := strings.Cut(s, "/")
+	if !ok {
+		return Prefix{}, fmt.Errorf("ipaddr: missing \"/\" in prefix %q", s)
+	}
+	a, err := ParseAddr(addr)
+	if err != nil {
+		return Prefix{}, err
+	}
+	// Like the bytes of IPv4 addresses, only plain decimal digits without sign or leading zeros are allowed
+	n, err := strconv.ParseUint(bits, 10, 8)
+	if err != nil || (len(bits) > 1 && bits[0] == '0') {
+		return Prefix{}, fmt.Errorf("ipaddr: invalid prefix length in %q", s)
+	}
+	return PrefixFrom(a, int(n))
+}
+
+// MustParsePrefix is like ParsePrefix, but panics on invalid input. It's meant for constants.
+func MustParsePrefix(s string) Prefix {
+	p, err := ParsePrefix(s)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Addr returns the address of the prefix, as it was parsed.
+func (p Prefix) Addr() Addr {
+	return p.addr
+}
+
+// Bits returns the prefix length.
+func (p Prefix) Bits() int {
+	return p.bits
+}
+
+// Masked returns the prefix with the host bits of the address cleared.
+func (p Prefix) Masked() Prefix {
+	return Prefix{p.Network(), p.bits}
+}
+
+// Network returns the first address of the prefix (all host bits 0).
+func (p Prefix) Network() Addr {
+	return p.withHostBits(0)
+}
+
+// Broadcast returns the last address of the prefix (all host bits 1).
+// IPv6 has no broadcast, but it's the last address as well.
+func (p Prefix) Broadcast() Addr {
+	return p.withHostBits(0xff)
+}
+
+// Contains reports whether the address is in the prefix.
+// IPv4 addresses are never in IPv6 prefixes and vice versa.
+func (p Prefix) Contains(a Addr) bool {
+	if a.ver != p.addr.ver {
+		return false
+	}
+	return Prefix{a, p.bits}.Network() == p.Network()
+}
+
+// Overlaps reports whether the prefixes have any addresses in common.
+func (p Prefix) Overlaps(o Prefix) bool {
+	if p.bits > o.bits {
+		p, o = o, p
+	}
+	return p.Contains(o.addr)
+}
+
+// All returns an iterator over all addresses of the prefix, from Network to Broadcast.
+func (p Prefix) All() iter.Seq[Addr] {
+	return Range(p.Network(), p.Broadcast())
+}
+
+// String returns the prefix in CIDR notation.
+func (p Prefix) String() string {
+	return fmt.Sprintf("%v/%v", p.addr, p.bits)
+}
+
+func (p Prefix) withHostBits(fill byte) Addr {
+	a := p.addr
+	// Position of the first host bit in the 16 bytes
+	first := 128 - a.BitLen() + p.bits
+	for i := first; i < 128; i++ {
+		mask := byte(0x80) >> uint(i%8)
+		if fill == 0 {
+			a.b[i/8] &^= mask
+		} else {
+			a.b[i/8] |= mask
+		}
+	}
+	return a
+}
+
+// ========
+
+// Range returns an iterator over all addresses from "from" to "to", including both.
+// It's empty if "to" is less than "from" or they're not of the same version.
+func Range(from, to Addr) iter.Seq[Addr] {
+	return func(yield func(Addr) bool) {
+		if from.ver != to.ver || from.Compare(to) > 0 {
+			return
+		}
+		for a, ok := from, true; ok; a, ok = a.Next() {
+			if !yield(a) || a == to {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package ipaddr
+
+import (
+	"net/netip"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseAddr(t *testing.T) {
+	tests := []struct {
+		in, want string // Empty want means invalid
+		ver      int
+	}{
+		{"192.168.0.1", "192.168.0.1", 4},
+		{"0.0.0.0", "0.0.0.0", 4},
+		{"255.255.255.255", "255.255.255.255", 4},
+		{"2001:db8::1", "2001:db8::1", 6},
+		{"2001:0DB8:0000:0000:0000:0000:0000:0001", "2001:db8::1", 6},
+		{"::", "::", 6},
+		{"::1", "::1", 6},
+		{"1::", "1::", 6},
+		{"::ffff:192.168.0.1", "::ffff:192.168.0.1", 6},
+		{"::ffff:c0a8:1", "::ffff:192.168.0.1", 6},
+		{"::1.2.3.4", "::102:304", 6},
+		{"1:2:3:4:5:6:1.2.3.4", "1:2:3:4:5:6:102:304", 6},
+		// RFC 5952: the longest run of zeros, the first one if there are several, and not a single group
+		{"1:0:0:2:0:0:0:3", "1:0:0:2::3", 6},
+		{"1:0:0:2:0:0:3:4", "1::2:0:0:3:4", 6},
+		{"1:0:2:3:4:5:6:7", "1:0:2:3:4:5:6:7", 6},
+		{"", "", 0},
+		{"1.2.3", "", 0},
+		{"1.2.3.4.5", "", 0},
+		{"1.2.3.256", "", 0},
+		{"01.2.3.4", "", 0},
+		{"1.2.3.+4", "", 0},
+		{"1.2.3.-0", "", 0},
+		{"1..2.3", "", 0},
+		{"1:2:3:4:5:6:7", "", 0},
+		{"1:2:3:4:5:6:7:8:9", "", 0},
+		{"1:2:3:4:5:6:7:8::", "", 0},
+		{"1::2::3", "", 0},
+		{":1::", "", 0},
+		{"12345::", "", 0},
+		{"g::", "", 0},
+		{"::1.2.3.4:5", "", 0},
+		{"1.2.3.4::", "", 0},
+		{"fe80::1%eth0", "", 0},
+	}
+	for _, test := range tests {
+		a, err := ParseAddr(test.in)
+		if test.want == "" {
+			if err == nil {
+				t.Errorf("ParseAddr(%q) = %v, want error", test.in, a)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseAddr(%q) = %v", test.in, err)
+			continue
+		}
+		if s := a.String(); s != test.want || a.Is4() != (test.ver == 4) || a.Is6() != (test.ver == 6) {
+			t.Errorf("ParseAddr(%q) = %v (Is4 %v), want %v (IPv%v)", test.in, s, a.Is4(), test.want, test.ver)
+		}
+	}
+}
+
+func TestAddr(t *testing.T) {
+	a4 := MustParseAddr("1.2.3.4")
+	mapped := MustParseAddr("::ffff:1.2.3.4")
+	if mapped == a4 || !mapped.Is4In6() || mapped.Unmap() != a4 || a4.Unmap() != a4 {
+		t.Errorf("Unmap(%v) = %v, want %v", mapped, mapped.Unmap(), a4)
+	}
+	if a4.As16() != mapped.As16() || a4.As4() != [4]byte{1, 2, 3, 4} {
+		t.Errorf("As16() = %v, As4() = %v", a4.As16(), a4.As4())
+	}
+	if AddrFrom4([4]byte{1, 2, 3, 4}) != a4 || AddrFrom16(mapped.As16()) != mapped {
+		t.Error("AddrFrom4 or AddrFrom16 differ from the parsed addresses")
+	}
+	var zero Addr
+	if zero.IsValid() || zero.String() != "invalid IP" || zero.BitLen() != 0 {
+		t.Errorf("zero Addr is %v", zero)
+	}
+
+	sorted := []Addr{MustParseAddr("1.2.3.4"), MustParseAddr("1.2.3.5"), MustParseAddr("::"), MustParseAddr("::1")}
+	for i := range sorted {
+		for j := range sorted {
+			if got, want := sorted[i].Compare(sorted[j]), cmpInt(i, j); got != want {
+				t.Errorf("Compare(%v, %v) = %v, want %v", sorted[i], sorted[j], got, want)
+			}
+		}
+	}
+
+	steps := []struct {
+		a, next string // Empty next means there is none
+	}{
+		{"1.2.3.4", "1.2.3.5"},
+		{"1.2.3.255", "1.2.4.0"},
+		{"255.255.255.255", ""},
+		{"::ffff", "::1:0"},
+		{"ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", ""},
+	}
+	for _, step := range steps {
+		a := MustParseAddr(step.a)
+		next, ok := a.Next()
+		if step.next == "" {
+			if ok {
+				t.Errorf("%v.Next() = %v, want none", a, next)
+			}
+			continue
+		}
+		if !ok || next.String() != step.next {
+			t.Errorf("%v.Next() = %v, %v, want %v", a, next, ok, step.next)
+		}
+		if prev, ok := next.Prev(); !ok || prev != a {
+			t.Errorf("%v.Prev() = %v, %v, want %v", next, prev, ok, a)
+		}
+	}
+	for _, first := range []string{"0.0.0.0", "::"} {
+		if prev, ok := MustParseAddr(first).Prev(); ok {
+			t.Errorf("%v.Prev() = %v, want none", first, prev)
+		}
+	}
+}
+
+func cmpInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func TestParsePrefix(t *testing.T) {
+	valid := []struct {
+		in, masked, broadcast string
+	}{
+		{"10.1.2.3/8", "10.0.0.0/8", "10.255.255.255"},
+		{"192.168.1.1/32", "192.168.1.1/32", "192.168.1.1"},
+		{"1.2.3.4/0", "0.0.0.0/0", "255.255.255.255"},
+		{"192.168.0.0/23", "192.168.0.0/23", "192.168.1.255"},
+		{"2001:db8::1/32", "2001:db8::/32", "2001:db8:ffff:ffff:ffff:ffff:ffff:ffff"},
+		{"::1/128", "::1/128", "::1"},
+	}
+	for _, test := range valid {
+		p, err := ParsePrefix(test.in)
+		if err != nil {
+			t.Errorf("ParsePrefix(%q) = %v", test.in, err)
+			continue
+		}
+		if p.String() != test.in {
+			t.Errorf("String() = %v, want %v", p, test.in)
+		}
+		if m := p.Masked().String(); m != test.masked {
+			t.Errorf("%v.Masked() = %v, want %v", p, m, test.masked)
+		}
+		if b := p.Broadcast().String(); b != test.broadcast {
+			t.Errorf("%v.Broadcast() = %v, want %v", p, b, test.broadcast)
+		}
+	}
+
+	invalid := []string{"1.2.3.4", "1.2.3.4/", "1.2.3.4/33", "::/129", "1.2.3.4/08", "1.2.3.4/+8", "1.2.3.4/-0", "1.2.3.4/ 8", "1.2.3.4/8 ", "1.2.3.4/0x8", "1.2.3.4/1_0", "1.2.3/8", "/8"}
+	for _, in := range invalid {
+		if p, err := ParsePrefix(in); err == nil {
+			t.Errorf("ParsePrefix(%q) = %v, want error", in, p)
+		}
+	}
+	if _, err := PrefixFrom(Addr{}, 0); err != ErrInvalidPrefix {
+		t.Errorf("PrefixFrom(invalid address) = %v, want %v", err, ErrInvalidPrefix)
+	}
+}
+
+func TestPrefixContains(t *testing.T) {
+	p := MustParsePrefix("10.0.0.0/8")
+	tests := []struct {
+		a    string
+		want bool
+	}{
+		{"10.0.0.0", true},
+		{"10.255.255.255", true},
+		{"11.0.0.0", false},
+		{"9.255.255.255", false},
+		{"::ffff:10.0.0.1", false}, // Different version
+	}
+	for _, test := range tests {
+		if got := p.Contains(MustParseAddr(test.a)); got != test.want {
+			t.Errorf("%v.Contains(%v) = %v, want %v", p, test.a, got, test.want)
+		}
+	}
+
+	overlaps := []struct {
+		a, b string
+		want bool
+	}{
+		{"10.0.0.0/8", "10.1.0.0/16", true},
+		{"10.1.0.0/16", "10.0.0.0/8", true},
+		{"10.0.0.0/16", "10.1.0.0/16", false},
+		{"0.0.0.0/0", "1.2.3.4/32", true},
+		{"2001:db8::/32", "2001:db8:1::/48", true},
+		{"::/0", "0.0.0.0/0", false},
+	}
+	for _, test := range overlaps {
+		if got := MustParsePrefix(test.a).Overlaps(MustParsePrefix(test.b)); got != test.want {
+			t.Errorf("%v.Overlaps(%v) = %v, want %v", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestRange(t *testing.T) {
+	var got []string
+	for a := range MustParsePrefix("192.168.0.5/30").All() {
+		got = append(got, a.String())
+	}
+	if want := []string{"192.168.0.4", "192.168.0.5", "192.168.0.6", "192.168.0.7"}; !slices.Equal(got, want) {
+		t.Errorf("All() = %v, want %v", got, want)
+	}
+
+	count := func(from, to string) int {
+		n := 0
+		for range Range(MustParseAddr(from), MustParseAddr(to)) {
+			n++
+		}
+		return n
+	}
+	tests := []struct {
+		from, to string
+		want     int
+	}{
+		{"1.2.3.4", "1.2.3.4", 1},
+		{"1.2.3.250", "1.2.4.5", 12},
+		{"255.255.255.254", "255.255.255.255", 2},
+		{"1.2.3.5", "1.2.3.4", 0},
+		{"1.2.3.4", "::ffff:1.2.3.5", 0},
+	}
+	for _, test := range tests {
+		if n := count(test.from, test.to); n != test.want {
+			t.Errorf("Range(%v, %v) has %v addresses, want %v", test.from, test.to, n, test.want)
+		}
+	}
+	// Breaking out of the loop early
+	for a := range MustParsePrefix("::/0").All() {
+		if a != MustParseAddr("::") {
+			t.Errorf("first address = %v", a)
+		}
+		break
+	}
+}
+
+// FuzzParseAddr compares ParseAddr with net/netip, which must agree on all inputs without zones.
+// Run with "go test -fuzz=FuzzParseAddr ./ipaddr/".
+func FuzzParseAddr(f *testing.F) {
+	for _, s := range []string{"1.2.3.4", "01.2.3.4", "::", "::1", "1::", "2001:db8::1", "::ffff:1.2.3.4", "1:2:3:4:5:6:7:8", "1:2:3:4:5:6:1.2.3.4", "1::2::3", "1.2.3.4.5", ":::", "0:0:0:0:0:0:0:0", "1:0:0:2:0:0:3:4"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		if strings.Contains(s, "%") {
+			return // Zones are only supported by netip
+		}
+		a, err := ParseAddr(s)
+		want, wantErr := netip.ParseAddr(s)
+		if (err != nil) != (wantErr != nil) {
+			t.Fatalf("ParseAddr(%q) = %v, %v, netip: %v, %v", s, a, err, want, wantErr)
+		}
+		if err != nil {
+			return
+		}
+		if a.String() != want.String() || a.As16() != want.As16() || a.Is4() != want.Is4() || a.Is4In6() != want.Is4In6() {
+			t.Fatalf("ParseAddr(%q) = %v, netip: %v", s, a, want)
+		}
+		// Formatting and parsing again gives the same address
+		if back, err := ParseAddr(a.String()); err != nil || back != a {
+			t.Fatalf("ParseAddr(%q) = %v, %v, want %v", a.String(), back, err, a)
+		}
+	})
+}
+
+// FuzzParsePrefix compares ParsePrefix with net/netip.
+func FuzzParsePrefix(f *testing.F) {
+	for _, s := range []string{"10.0.0.0/8", "1.2.3.4/32", "1.2.3.4/33", "1.2.3.4/08", "1.2.3.4/+8", "1.2.3.4/-0", "2001:db8::/32", "::/0", "::ffff:1.2.3.4/100"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		if strings.Contains(s, "%") {
+			return
+		}
+		p, err := ParsePrefix(s)
+		want, wantErr := netip.ParsePrefix(s)
+		if (err != nil) != (wantErr != nil) {
+			t.Fatalf("ParsePrefix(%q) = %v, %v, netip: %v, %v", s, p, err, want, wantErr)
+		}
+		if err == nil && (p.String() != want.String() || p.Masked().String() != want.Masked().String()) {
+			t.Fatalf("ParsePrefix(%q) = %v (%v), netip: %v (%v)", s, p, p.Masked(), want, want.Masked())
+		}
+	})
+}
@@ -0,0 +1,127 @@
+// Package leakcheck finds goroutines that are still running when they shouldn't be anymore,
+// like a "Walk" that blocks on a channel nobody reads from.
+//
+// In a test:
+//
+//	func TestSame(t *testing.T) {
+//		defer leakcheck.Check(t)()
+//		...
+//	}
+package leakcheck
+
+import (
+	"bytes"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/philippgille/hello-go/internal/goid"
+)
+
+// Timeout is how long Check waits for goroutines to return before it reports them as leaked.
+var Timeout = time.Second
+
+// TB is the part of testing.TB that's used by Check.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// Goroutine is a running goroutine.
+type Goroutine struct {
+	ID    uint64
+	State string // Like "chan receive" or "select"
+	Stack string // The full stack trace, including the header line
+}
+
+// Snapshot contains the IDs of the goroutines that were running when it was taken.
+type Snapshot map[uint64]bool
+
+// Take returns a Snapshot of the currently running goroutines.
+func Take() Snapshot {
+	s := make(Snapshot)
+	for _, g := range running() {
+		s[g.ID] = true
+	}
+	return s
+}
+
+// Leaked returns the goroutines that were started after the Snapshot was taken and are still running.
+// Because goroutines can take a moment to return, it retries until timeout.
+// Goroutines whose stack contains any of the ignore strings (like a function name) are ignored.
+func (s Snapshot) Leaked(timeout time.Duration, ignore ...string) []Goroutine {
+	deadline := time.Now().Add(timeout)
+	wait := time.Millisecond
+	for {
+		var leaked []Goroutine
+		self := goid.Current()
+		for _, g := range running() {
+			if !s[g.ID] && g.ID != self && !contains(g.Stack, ignore) {
+				leaked = append(leaked, g)
+			}
+		}
+		if len(leaked) == 0 || time.Now().After(deadline) {
+			return leaked
+		}
+		time.Sleep(wait)
+		if wait < 100*time.Millisecond {
+			wait *= 2
+		}
+	}
+}
+
+// Check takes a Snapshot and returns a function that fails the test
+// with the stack traces of all goroutines that were started since then and are still running after Timeout.
+// Call the returned function with "defer" at the beginning of the test.
+func Check(t TB, ignore ...string) func() {
+	s := Take()
+	return func() {
+		t.Helper()
+		for _, g := range s.Leaked(Timeout, ignore...) {
+			t.Errorf("leaked goroutine:\n%s", g.Stack)
+		}
+	}
+}
+
+// ========
+
+// running parses the stack traces of all goroutines
+func running() []Goroutine {
+	buf := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	var result []Goroutine
+	// Stack traces are separated by empty lines
+	for _, stack := range bytes.Split(buf, []byte("\n\n")) {
+		if g, ok := parse(string(stack)); ok {
+			result = append(result, g)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+// parse parses a stack trace that starts with a header like "goroutine 7 [chan send]:"
+func parse(stack string) (Goroutine, bool) {
+	id, state, ok := goid.Parse(stack)
+	return Goroutine{ID: id, State: state, Stack: stack}, ok
+}
+
+func contains(stack string, ignore []string) bool {
+	for _, s := range ignore {
+		if strings.Contains(stack, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package leakcheck
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recorder is a TB that records the errors instead of failing the test
+type recorder struct {
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// leakyWalk blocks forever on sending, like a "Walk" whose receiver returned early
+func leakyWalk(ch chan int) {
+	ch <- 1
+}
+
+func TestCheckReportsLeak(t *testing.T) {
+	timeout := Timeout
+	Timeout = 10 * time.Millisecond
+	defer func() {
+		Timeout = timeout
+	}()
+
+	ch := make(chan int)
+	r := &recorder{}
+	check := Check(r)
+	go leakyWalk(ch)
+	check()
+	if len(r.errors) != 1 || !strings.Contains(r.errors[0], "leakcheck.leakyWalk") {
+		t.Errorf("Check reported %q, want the leaked goroutine", r.errors)
+	}
+
+	// Ignored by name
+	r = &recorder{}
+	Check(r, "leakyWalk")()
+	if len(r.errors) != 0 {
+		t.Errorf("Check reported %q, want nothing for ignored goroutines", r.errors)
+	}
+
+	// Goroutines that were running before aren't reported
+	r = &recorder{}
+	Check(r)()
+	if len(r.errors) != 0 {
+		t.Errorf("Check reported %q, want nothing for goroutines that were already running", r.errors)
+	}
+
+	// Stop the leaked goroutine, so it doesn't leak from this test
+	<-ch
+}
+
+func TestLeaked(t *testing.T) {
+	s := Take()
+	ch := make(chan int)
+	go leakyWalk(ch)
+	leaked := s.Leaked(10 * time.Millisecond)
+	if len(leaked) != 1 {
+		t.Fatalf("Leaked() = %v goroutines, want 1", len(leaked))
+	}
+	if g := leaked[0]; g.State != "chan send" || !strings.HasPrefix(g.Stack, fmt.Sprintf("goroutine %v [", g.ID)) {
+		t.Errorf("Leaked() = %+v, want goroutine in state \"chan send\"", g)
+	}
+
+	// Goroutines that return while Leaked waits aren't reported
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		<-ch
+	}()
+	if leaked := s.Leaked(time.Second); len(leaked) != 0 {
+		t.Errorf("Leaked() = %v goroutines, want none after they returned", len(leaked))
+	}
+}
+
+func TestParse(t *testing.T) {
+	g, ok := parse("goroutine 7 [chan receive, 2 minutes]:\nmain.main()")
+	if !ok || g.ID != 7 || g.State != "chan receive" || !strings.HasSuffix(g.Stack, "main.main()") {
+		t.Errorf("parse() = %+v, %v", g, ok)
+	}
+	if _, ok := parse("main.main()"); ok {
+		t.Error("parse() of a stack trace without header is ok")
+	}
+}
@@ -1,15 +1,30 @@
 package main
 
 import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"image"
 	"image/color"
 	"io"
 	"math"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/philippgille/hello-go/cipher"
+	"github.com/philippgille/hello-go/errs"
+	"github.com/philippgille/hello-go/ipaddr"
+	"github.com/philippgille/hello-go/nthroot"
+	"github.com/philippgille/hello-go/picture"
+	"github.com/philippgille/hello-go/readers"
+	"github.com/philippgille/hello-go/shape"
+	"github.com/philippgille/hello-go/solve"
+	"github.com/philippgille/hello-go/vector"
 	"golang.org/x/tour/pic"
 	"golang.org/x/tour/reader"
 )
@@ -47,6 +62,14 @@ func scaleAsFunction(v *vertex, f float64) {
 	v.Y = v.Y * f
 }
 
+// The "vector" package offers the same (and much more) for 2D and 3D vectors
+func myVector() {
+	v := vertex{3, 4}
+	w := vector.Vec2[float64]{X: v.X, Y: v.Y}
+	fmt.Println(w, w.Len(), w.Scale(10).Len()) // (3, 4) 5 50
+	fmt.Println(w.Normalize(), w.Rotate(math.Pi/2), w.Angle(vector.Vec2[float64]{X: 1}))
+}
+
 // ===============
 
 // An interface is a type that defines a set of method signatures
@@ -89,6 +112,18 @@ func myInterface() {
 	fmt.Println(a.abs())
 }
 
+// The "shape" package has an interface with more methods, implemented by multiple shapes.
+// A slice of the interface type can contain all of them.
+func myShapes() {
+	shapes := []shape.Shape{
+		shape.Circle{Center: shape.Point{X: 0, Y: 0}, Radius: 1},
+		shape.Rectangle{Min: shape.Point{X: 0, Y: 0}, Max: shape.Point{X: 2, Y: 3}},
+		shape.Triangle{A: shape.Point{X: 3, Y: 0}, B: shape.Point{X: 7, Y: 0}, C: shape.Point{X: 3, Y: 3}},
+	}
+	fmt.Println(shape.TotalArea(shapes))
+	fmt.Println(shape.Intersects(shapes[0], shapes[1]), shape.Intersects(shapes[0], shapes[2]))
+}
+
 // ==============
 
 type i interface {
@@ -235,6 +270,59 @@ func (p person) String() string {
 	return fmt.Sprintf("%v (%v years)", p.Name, p.Age)
 }
 
+// The text representation is the same as String(), like "Arthur Dent (42 years)"
+
+// MarshalText implements encoding.TextMarshaler
+func (p person) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (p *person) UnmarshalText(text []byte) error {
+	// Cut at the last " (", because the name could contain one as well
+	s := string(text)
+	i := strings.LastIndex(s, " (")
+	if i < 0 || !strings.HasSuffix(s, " years)") {
+		return fmt.Errorf("invalid person: %q", s)
+	}
+	age, err := strconv.Atoi(s[i+2 : len(s)-len(" years)")])
+	if err != nil {
+		return fmt.Errorf("invalid age in person %q", s)
+	}
+	*p = person{s[:i], age}
+	return nil
+}
+
+// JSON uses the text representation when there's a TextMarshaler, but a JSON object is more useful for persons.
+// The type conversion to personJSON drops the methods, so encoding/json uses its default for structs instead of calling itself recursively.
+type personJSON person
+
+// MarshalJSON implements json.Marshaler
+func (p person) MarshalJSON() ([]byte, error) {
+	return json.Marshal(personJSON(p)) // {"Name":"Arthur Dent","Age":42}
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (p *person) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, (*personJSON)(p))
+}
+
+// Value implements driver.Valuer
+func (p person) Value() (driver.Value, error) {
+	return p.String(), nil
+}
+
+// Scan implements sql.Scanner
+func (p *person) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case string:
+		return p.UnmarshalText([]byte(src))
+	case []byte:
+		return p.UnmarshalText(src)
+	}
+	return fmt.Errorf("can't scan %T into person", src)
+}
+
 // ============
 
 type ipAddr [4]byte
@@ -252,6 +340,137 @@ func stringerExercise() {
 	for name, ip := range hosts {
 		fmt.Printf("%v: %v\n", name, ip)
 	}
+
+	// The "ipaddr" package supports IPv6 and CIDR prefixes as well
+	loopback := ipaddr.MustParsePrefix("127.0.0.0/8")
+	for name, ip := range hosts {
+		fmt.Printf("%v in %v: %v\n", name, loopback, loopback.Contains(ip.addr()))
+	}
+	fmt.Println(ipaddr.MustParseAddr("2001:0db8:0000:0000:0000:0000:0000:0001")) // 2001:db8::1
+
+	// Save the hosts to a JSON config file and load them again
+	path := filepath.Join(os.TempDir(), "hosts.json")
+	f, err := os.Create(path)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.Remove(path)
+	err = saveHosts(f, hosts)
+	f.Close()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	f, err = os.Open(path)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer f.Close()
+	loaded, err := loadHosts(f)
+	fmt.Println(loaded, err) // map[googleDNS:8.8.8.8 loopback:127.0.0.1] <nil>
+}
+
+// addr converts the address for use with the "ipaddr" package
+func (ip ipAddr) addr() ipaddr.Addr {
+	return ipaddr.AddrFrom4(ip)
+}
+
+// Marshaling: The String() output can be parsed back, so it's used as text representation.
+// With TextMarshaler and TextUnmarshaler, ipAddr is a JSON string and can even be a JSON object key.
+// Unmarshaling needs pointer receivers, so this is one of the rare cases where receivers are mixed.
+
+// MarshalText implements encoding.TextMarshaler
+func (ip ipAddr) MarshalText() ([]byte, error) {
+	return []byte(ip.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (ip *ipAddr) UnmarshalText(text []byte) error {
+	a, err := ipaddr.ParseAddr(string(text))
+	if err != nil {
+		return err
+	}
+	if !a.Is4() {
+		return fmt.Errorf("not an IPv4 address: %q", text)
+	}
+	*ip = a.As4()
+	return nil
+}
+
+// Value implements driver.Valuer, so ipAddr can be used as SQL query argument
+func (ip ipAddr) Value() (driver.Value, error) {
+	return ip.String(), nil
+}
+
+// Scan implements sql.Scanner, so ipAddr can be read from a SQL text column
+func (ip *ipAddr) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case string:
+		return ip.UnmarshalText([]byte(src))
+	case []byte:
+		return ip.UnmarshalText(src)
+	}
+	return fmt.Errorf("can't scan %T into ipAddr", src)
+}
+
+// saveHosts writes the hosts as JSON object, like {"googleDNS": "8.8.8.8"}
+func saveHosts(w io.Writer, hosts map[string]ipAddr) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(hosts)
+}
+
+// loadHosts reads hosts that were written by saveHosts
+func loadHosts(r io.Reader) (map[string]ipAddr, error) {
+	var hosts map[string]ipAddr
+	if err := json.NewDecoder(r).Decode(&hosts); err != nil {
+		return nil, err
+	}
+	return hosts, nil
+}
+
+// saveHostsSQL stores the hosts in the "hosts" table, which is created if it doesn't exist.
+// Any driver that uses "?" as placeholder works, like SQLite or MySQL.
+func saveHostsSQL(db *sql.DB, hosts map[string]ipAddr) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // No-op after Commit
+	if _, err := tx.Exec("CREATE TABLE IF NOT EXISTS hosts (name TEXT PRIMARY KEY, ip TEXT NOT NULL)"); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM hosts"); err != nil {
+		return err
+	}
+	for name, ip := range hosts {
+		// ip is converted by its Value method
+		if _, err := tx.Exec("INSERT INTO hosts (name, ip) VALUES (?, ?)", name, ip); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// loadHostsSQL reads hosts that were stored by saveHostsSQL
+func loadHostsSQL(db *sql.DB) (map[string]ipAddr, error) {
+	rows, err := db.Query("SELECT name, ip FROM hosts")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	hosts := map[string]ipAddr{}
+	for rows.Next() {
+		var name string
+		var ip ipAddr // Converted by its Scan method
+		if err := rows.Scan(&name, &ip); err != nil {
+			return nil, err
+		}
+		hosts[name] = ip
+	}
+	return hosts, rows.Err()
 }
 
 // ==========
@@ -267,12 +486,13 @@ func (e *myError) Error() string {
 		e.When, e.What)
 }
 
-// When "error" gets returned, it can be any custom type as long as "Error()" is implemented for that type
+// When "error" gets returned, it can be any custom type as long as "Error()" is implemented for that type.
+// The "errs" package adds a code, fields and a cause to errors. Here myError is the cause.
 func run() error {
-	return &myError{
+	return errs.Wrap(&myError{
 		time.Now(),
 		"it didn't work",
-	}
+	}, errs.Internal, "run failed", "attempt", 1)
 }
 
 // ==========
@@ -289,17 +509,29 @@ func mySqrt(x float64) (float64, error) {
 
 	// Check precondition
 	if x < 0 {
-		return r, errNegativeSqrt(x)
+		// Empty message, so the error message stays the one of errNegativeSqrt.
+		// The stack trace shows where the error was created, which is here.
+		return r, errs.WrapWithStack(errNegativeSqrt(x), errs.InvalidArgument, "", "x", x)
 	}
-
-	r = 1.0
-	old := 0.0
-	for r != old {
-		old = r
-		r -= (r*r - x) / (2 * r)
+	// The mantissa of 0 is 0, where Newton's method would stop at its tolerance instead of at 0, and Inf and NaN have none
+	if x == 0 || math.IsInf(x, 1) || math.IsNaN(x) {
+		return x, nil
 	}
 
-	return r, nil
+	// Newton's method of the "solve" package stops after a maximum number of iterations,
+	// while "for r != old" can loop forever when r oscillates between two values.
+	// Like "MySqrt2" in the flowcontrol chapter it only runs on the mantissa, so starting at 1 is close enough for any x.
+	frac, exp := math.Frexp(x) // x = frac * 2^exp
+	if exp%2 != 0 {
+		frac *= 2
+		exp--
+	}
+	res, err := solve.Newton(
+		func(r float64) float64 { return r*r - frac },
+		func(r float64) float64 { return 2 * r },
+		1, solve.Options{},
+	)
+	return math.Ldexp(res.Root, exp/2), err
 }
 
 // ===========
@@ -331,6 +563,27 @@ func (r aReader) Read(b []byte) (int, error) {
 	return len(b), nil
 }
 
+// The "readers" package has readers like aReader (readers.Repeat([]byte("A"))) that can be combined
+func myReaders() {
+	reader.Validate(readers.Repeat([]byte("A")))
+
+	r := readers.Section(readers.Repeat([]byte("abc")), 1, 7)
+	r = readers.Progress(r, func(total int64) { fmt.Printf("read %v bytes\n", total) })
+	b, _ := io.ReadAll(r)
+	fmt.Printf("%s\n", b) // bcabcab
+
+	// A consumer that doesn't handle short reads would get "0123" and then only "4"
+	r = readers.ShortReadsAfter(strings.NewReader("0123456789"), 4, 1)
+	buf := make([]byte, 4)
+	_, err := io.ReadFull(r, buf)
+	fmt.Printf("%s %v\n", buf, err) // 0123 <nil>
+	_, err = io.ReadFull(r, buf)
+	fmt.Printf("%s %v\n", buf, err) // 4567 <nil>
+
+	b, err = io.ReadAll(readers.ErrorAfter(readers.Random(42), 16, nil))
+	fmt.Println(len(b), err) // 16 readers: injected error
+}
+
 // ===========
 
 type rot13Reader struct {
@@ -339,36 +592,37 @@ type rot13Reader struct {
 
 // Reader exercise 2
 func (rot13r *rot13Reader) Read(b []byte) (int, error) {
-	fmt.Printf("Before reading, first 100 bytes: %v\n", b[:100])
-	var read int
 	read, err := rot13r.r.Read(b)
-	if err != nil && err != io.EOF {
-		fmt.Printf("Error: %v\n", err)
-		return 0, err
-	}
-	fmt.Printf("After reading: %v\n", string(b[:read]))
+	// Read can return data and an error (like io.EOF) at the same time,
+	// so the data must be rotated and the error passed on, without returning io.EOF before the end of the stream
 	for i := 0; i < read; i++ {
-		//fmt.Printf("Before rotation: %v\n", string(b[i]))
 		b[i] = rot13(b[i])
-		//fmt.Printf("After rotation: %v\n", string(b[i]))
 	}
-	fmt.Printf("Whole byte array after rotation: %v\n", string(b[:read]))
-	return read, io.EOF
+	return read, err
 }
 
 func rot13(b byte) byte {
-	alphabet := "abcdefghijklmnopqrstuvwxyz"
-	rot13 := "nopqrstuvwxyzabcdefghijklm"
-	lowercaseLetter := strings.ToLower(string(b))
-	alphabetIndex := strings.Index(alphabet, lowercaseLetter)
+	alphabet := "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	rot13 := "nopqrstuvwxyzabcdefghijklmNOPQRSTUVWXYZABCDEFGHIJKLM"
+	alphabetIndex := strings.IndexByte(alphabet, b)
 	// Only rotate found letters, keep all others (like space and exclamation mark)
 	if alphabetIndex != -1 {
-		rot13Letter := rot13[alphabetIndex]
-		return rot13Letter
+		return rot13[alphabetIndex]
 	}
 	return b
 }
 
+// The "cipher" package has readers and writers for more ciphers
+func myCiphers() {
+	c, _ := cipher.Vigenere("LEMON")
+	s := cipher.Encrypt(c, "Attack at dawn!")
+	fmt.Println(s) // Lxfopv ef rnhr!
+	io.Copy(os.Stdout, cipher.NewDecryptReader(strings.NewReader(s), c))
+	fmt.Println() // Attack at dawn!
+	w := cipher.NewEncryptWriter(os.Stdout, cipher.ROT47)
+	fmt.Fprintln(w, "Hello, Reader!") // w6==@[ #6256CP
+}
+
 // ==============
 
 type myImage struct{}
@@ -419,6 +673,10 @@ func main() {
 	// and can also be more efficient, because the value doesn't need to be copied
 	// All methods on a given type should have either value or pointer receivers, but not a mixture of both
 
+	myVector()
+
+	myShapes()
+
 	var i i = t{"hello"}
 	i.M()
 
@@ -442,26 +700,52 @@ func main() {
 	a := person{"Arthur Dent", 42}
 	z := person{"Zaphod Beeblebrox", 9001}
 	fmt.Println(a, z)
+	data, _ := json.Marshal(a)
+	fmt.Println(string(data)) // {"Name":"Arthur Dent","Age":42}
+	var a2 person
+	if err := a2.UnmarshalText([]byte(z.String())); err == nil {
+		fmt.Println(a2 == z) // true
+	}
 
 	stringerExercise()
 
 	if err := run(); err != nil {
 		// Prints according to custom error format
-		fmt.Println(err) // at 2018-04-09 19:57:11.1472173 +0200 CEST m=+0.012693401, it didn't work
+		fmt.Println(err)                           // run failed: at 2018-04-09 19:57:11.1472173 +0200 CEST m=+0.012693401, it didn't work
+		fmt.Println(errors.Is(err, errs.Internal)) // true
+		// errors.As finds the cause with its specific type
+		var me *myError
+		if errors.As(err, &me) {
+			fmt.Println(me.What) // it didn't work
+		}
+		fmt.Println(errs.FieldsOf(err)) // map[attempt:1]
 	}
 
 	fmt.Println(mySqrt(20))
 	fmt.Println(mySqrt(-20))
+	fmt.Println(nthroot.ComplexSqrt(-20)) // (0+4.47213595499958i), no error needed in complex mode
+	if _, err := mySqrt(-20); err != nil {
+		fmt.Println(errs.CodeOf(err)) // invalid_argument
+		// errors.As instead of a type assertion, because err could be wrapped
+		var e *errs.Error
+		if errors.As(err, &e) {
+			data, _ := json.Marshal(e)
+			fmt.Println(string(data)) // {"code":"invalid_argument","message":"","when":...,"fields":{"x":-20},"stack":[...],"cause":{"message":"cannot Sqrt negative number: -20"}}
+		}
+	}
 
 	myReader()
 
 	reader.Validate(aReader{})
+	myReaders()
 
 	s := strings.NewReader("Lbh penpxrq gur pbqr!")
 	r := rot13Reader{s}
 	io.Copy(os.Stdout, &r)
 	fmt.Println()
 
+	myCiphers()
+
 	// Image
 	m := image.NewRGBA(image.Rect(0, 0, 100, 100))
 	fmt.Println(m.Bounds())
@@ -469,4 +753,12 @@ func main() {
 
 	m2 := myImage{}
 	pic.ShowImage(m2)
+
+	// pic.ShowImage is only meant for the Tour of Go website, the "picture" package saves images as files
+	path := filepath.Join(os.TempDir(), "myimage.png")
+	if err := picture.Save(path, m2); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("Saved image to", path)
 }
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/philippgille/hello-go/errs"
+)
+
+func TestPersonText(t *testing.T) {
+	persons := []person{
+		{"Arthur Dent", 42},
+		{"", 0},
+		{"Ford Prefect (from Betelgeuse)", 200},
+		{"Ford (42 years)", 42},
+		{"Marvin (", -1},
+	}
+	for _, p := range persons {
+		text, err := p.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got person
+		if err := got.UnmarshalText(text); err != nil || got != p {
+			t.Errorf("UnmarshalText(%q) = %#v, %v, want %#v", text, got, err, p)
+		}
+	}
+	for _, invalid := range []string{"Arthur Dent", "Arthur Dent (42)", "Arthur Dent (x years)", "Arthur Dent(42 years)"} {
+		var p person
+		if err := p.UnmarshalText([]byte(invalid)); err == nil {
+			t.Errorf("UnmarshalText(%q) = %#v, want error", invalid, p)
+		}
+	}
+}
+
+func TestPersonJSON(t *testing.T) {
+	p := person{"Ford Prefect (from Betelgeuse)", 200}
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"Name":"Ford Prefect (from Betelgeuse)","Age":200}`; string(data) != want {
+		t.Errorf("Marshal() = %s, want %s", data, want)
+	}
+	var got person
+	if err := json.Unmarshal(data, &got); err != nil || got != p {
+		t.Errorf("Unmarshal() = %#v, %v, want %#v", got, err, p)
+	}
+
+	// As map key the text representation is used
+	ages := map[person]bool{p: true, {"Arthur Dent", 42}: false}
+	data, err = json.Marshal(ages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gotAges map[person]bool
+	if err := json.Unmarshal(data, &gotAges); err != nil || !reflect.DeepEqual(gotAges, ages) {
+		t.Errorf("Unmarshal(%s) = %v, %v, want %v", data, gotAges, err, ages)
+	}
+}
+
+func TestIPAddrText(t *testing.T) {
+	for _, ip := range []ipAddr{{127, 0, 0, 1}, {0, 0, 0, 0}, {255, 255, 255, 255}, {8, 8, 8, 8}} {
+		text, err := ip.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got ipAddr
+		if err := got.UnmarshalText(text); err != nil || got != ip {
+			t.Errorf("UnmarshalText(%q) = %v, %v, want %v", text, got, err, ip)
+		}
+	}
+	for _, invalid := range []string{"", "1.2.3", "1.2.3.256", "::1", "::ffff:1.2.3.4"} {
+		var ip ipAddr
+		if err := ip.UnmarshalText([]byte(invalid)); err == nil {
+			t.Errorf("UnmarshalText(%q) = %v, want error", invalid, ip)
+		}
+	}
+}
+
+func TestIPAddrJSON(t *testing.T) {
+	hosts := map[string]ipAddr{
+		"loopback":  {127, 0, 0, 1},
+		"googleDNS": {8, 8, 8, 8},
+	}
+	var buf bytes.Buffer
+	if err := saveHosts(&buf, hosts); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"googleDNS": "8.8.8.8"`) {
+		t.Errorf("saveHosts() wrote %s", buf.String())
+	}
+	got, err := loadHosts(&buf)
+	if err != nil || !reflect.DeepEqual(got, hosts) {
+		t.Errorf("loadHosts() = %v, %v, want %v", got, err, hosts)
+	}
+	if _, err := loadHosts(strings.NewReader(`{"x": "1.2.3"}`)); err == nil {
+		t.Error("loadHosts() with an invalid address = nil error")
+	}
+
+	// ipAddr can be a key as well
+	names := map[ipAddr]string{{127, 0, 0, 1}: "loopback"}
+	data, err := json.Marshal(names)
+	if err != nil || string(data) != `{"127.0.0.1":"loopback"}` {
+		t.Errorf("Marshal() = %s, %v", data, err)
+	}
+	var gotNames map[ipAddr]string
+	if err := json.Unmarshal(data, &gotNames); err != nil || !reflect.DeepEqual(gotNames, names) {
+		t.Errorf("Unmarshal() = %v, %v, want %v", gotNames, err, names)
+	}
+}
+
+func TestScan(t *testing.T) {
+	var ip ipAddr
+	for _, src := range []interface{}{"1.2.3.4", []byte("1.2.3.4")} {
+		if err := ip.Scan(src); err != nil || ip != (ipAddr{1, 2, 3, 4}) {
+			t.Errorf("Scan(%#v) = %v, %v", src, ip, err)
+		}
+	}
+	var p person
+	for _, src := range []interface{}{"Arthur Dent (42 years)", []byte("Arthur Dent (42 years)")} {
+		if err := p.Scan(src); err != nil || p != (person{"Arthur Dent", 42}) {
+			t.Errorf("Scan(%#v) = %v, %v", src, p, err)
+		}
+	}
+	for _, src := range []interface{}{nil, int64(1), "invalid"} {
+		if err := ip.Scan(src); err == nil {
+			t.Errorf("ipAddr.Scan(%#v) = nil error", src)
+		}
+		if err := p.Scan(src); err == nil {
+			t.Errorf("person.Scan(%#v) = nil error", src)
+		}
+	}
+	if v, err := (ipAddr{1, 2, 3, 4}).Value(); err != nil || v != "1.2.3.4" {
+		t.Errorf("Value() = %#v, %v", v, err)
+	}
+}
+
+func TestMySqrt(t *testing.T) {
+	// The same start value as "MySqrt2" in the flowcontrol chapter, so large and small numbers don't need more than the default iterations
+	for _, x := range []float64{1, 2, 20, 1e-300, 1e300, math.MaxFloat64} {
+		got, err := mySqrt(x)
+		if want := math.Sqrt(x); err != nil || math.Abs(got-want) > 1e-15*want {
+			t.Errorf("mySqrt(%v) = %v, %v, want %v", x, got, err, want)
+		}
+	}
+	for _, x := range []float64{0, math.Copysign(0, -1), math.Inf(1), math.NaN()} {
+		got, err := mySqrt(x)
+		if err != nil || math.Float64bits(got) != math.Float64bits(x) {
+			t.Errorf("mySqrt(%v) = %v, %v, want %v", x, got, err, x)
+		}
+	}
+	_, err := mySqrt(-20)
+	var neg errNegativeSqrt
+	if !errors.As(err, &neg) || neg != -20 || !errors.Is(err, errs.InvalidArgument) || err.Error() != "cannot Sqrt negative number: -20" {
+		t.Errorf("mySqrt(-20) = %v", err)
+	}
+}
+
+// ========
+
+// hostsDriver is a database/sql driver that only understands the statements of saveHostsSQL and loadHostsSQL.
+// It stores the "hosts" table in memory and returns the ip column as []byte, like the MySQL driver.
+type hostsDriver struct {
+	mux    sync.Mutex
+	table  map[string]driver.Value // nil until it's created
+	failOn string                  // Statements with this prefix fail
+}
+
+var errInjected = errors.New("injected error")
+
+func (d *hostsDriver) Open(name string) (driver.Conn, error) {
+	return &hostsConn{d: d}, nil
+}
+
+// Connector makes it possible to use the driver with sql.OpenDB, without registering it
+func (d *hostsDriver) Connect(context.Context) (driver.Conn, error) {
+	return d.Open("")
+}
+
+func (d *hostsDriver) Driver() driver.Driver {
+	return d
+}
+
+type hostsConn struct {
+	d  *hostsDriver
+	tx map[string]driver.Value // Changes of the running transaction
+}
+
+func (c *hostsConn) Prepare(query string) (driver.Stmt, error) {
+	return &hostsStmt{c, query}, nil
+}
+
+func (c *hostsConn) Close() error {
+	return nil
+}
+
+func (c *hostsConn) Begin() (driver.Tx, error) {
+	c.d.mux.Lock()
+	defer c.d.mux.Unlock()
+	if c.d.table != nil {
+		c.tx = make(map[string]driver.Value, len(c.d.table))
+		for k, v := range c.d.table {
+			c.tx[k] = v
+		}
+	}
+	return c, nil
+}
+
+func (c *hostsConn) Commit() error {
+	c.d.mux.Lock()
+	defer c.d.mux.Unlock()
+	c.d.table, c.tx = c.tx, nil
+	return nil
+}
+
+func (c *hostsConn) Rollback() error {
+	c.tx = nil
+	return nil
+}
+
+type hostsStmt struct {
+	c     *hostsConn
+	query string
+}
+
+func (s *hostsStmt) Close() error {
+	return nil
+}
+
+func (s *hostsStmt) NumInput() int {
+	return strings.Count(s.query, "?")
+}
+
+func (s *hostsStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if s.c.d.failOn != "" && strings.HasPrefix(s.query, s.c.d.failOn) {
+		return nil, errInjected
+	}
+	switch {
+	case strings.HasPrefix(s.query, "CREATE TABLE IF NOT EXISTS hosts "):
+		if s.c.tx == nil {
+			s.c.tx = map[string]driver.Value{}
+		}
+	case s.query == "DELETE FROM hosts":
+		clear(s.c.tx)
+	case s.query == "INSERT INTO hosts (name, ip) VALUES (?, ?)":
+		name, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("name is %T, want string", args[0])
+		}
+		// The Valuer must turn ipAddr into a string, arrays aren't valid driver values
+		if _, ok := args[1].(string); !ok {
+			return nil, fmt.Errorf("ip is %T, want string", args[1])
+		}
+		if _, ok := s.c.tx[name]; ok {
+			return nil, fmt.Errorf("duplicate name %q", name)
+		}
+		s.c.tx[name] = args[1]
+	default:
+		return nil, fmt.Errorf("unexpected statement %q", s.query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *hostsStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if s.query != "SELECT name, ip FROM hosts" {
+		return nil, fmt.Errorf("unexpected query %q", s.query)
+	}
+	s.c.d.mux.Lock()
+	defer s.c.d.mux.Unlock()
+	if s.c.d.table == nil {
+		return nil, errors.New("no such table: hosts")
+	}
+	rows := &hostsRows{}
+	for name, ip := range s.c.d.table {
+		rows.values = append(rows.values, []driver.Value{name, []byte(ip.(string))})
+	}
+	return rows, nil
+}
+
+type hostsRows struct {
+	values [][]driver.Value
+}
+
+func (r *hostsRows) Columns() []string {
+	return []string{"name", "ip"}
+}
+
+func (r *hostsRows) Close() error {
+	return nil
+}
+
+func (r *hostsRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func TestHostsSQL(t *testing.T) {
+	d := &hostsDriver{}
+	db := sql.OpenDB(d)
+	defer db.Close()
+
+	if _, err := loadHostsSQL(db); err == nil {
+		t.Error("loadHostsSQL() without table = nil error")
+	}
+
+	hosts := map[string]ipAddr{
+		"loopback":  {127, 0, 0, 1},
+		"googleDNS": {8, 8, 8, 8},
+	}
+	if err := saveHostsSQL(db, hosts); err != nil {
+		t.Fatal(err)
+	}
+	if got := d.table["googleDNS"]; got != "8.8.8.8" {
+		t.Errorf("stored %#v, want \"8.8.8.8\"", got)
+	}
+	got, err := loadHostsSQL(db)
+	if err != nil || !reflect.DeepEqual(got, hosts) {
+		t.Errorf("loadHostsSQL() = %v, %v, want %v", got, err, hosts)
+	}
+
+	// Saving again replaces the hosts
+	hosts = map[string]ipAddr{"cloudflare": {1, 1, 1, 1}}
+	if err := saveHostsSQL(db, hosts); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := loadHostsSQL(db); err != nil || !reflect.DeepEqual(got, hosts) {
+		t.Errorf("loadHostsSQL() = %v, %v, want %v", got, err, hosts)
+	}
+
+	// A failing insert rolls back the whole transaction
+	d.failOn = "INSERT"
+	if err := saveHostsSQL(db, map[string]ipAddr{"loopback": {127, 0, 0, 1}}); !errors.Is(err, errInjected) {
+		t.Errorf("saveHostsSQL() = %v, want %v", err, errInjected)
+	}
+	d.failOn = ""
+	if got, err := loadHostsSQL(db); err != nil || !reflect.DeepEqual(got, hosts) {
+		t.Errorf("loadHostsSQL() after rollback = %v, %v, want %v", got, err, hosts)
+	}
+
+	// Invalid addresses in the table are reported by Scan
+	d.table["broken"] = "1.2.3"
+	if _, err := loadHostsSQL(db); err == nil || !strings.Contains(err.Error(), "1.2.3") {
+		t.Errorf("loadHostsSQL() with an invalid address = %v, want error", err)
+	}
+}
@@ -3,8 +3,15 @@ package main
 import (
 	"fmt"
 	"math"
+	"math/big"
+	"os"
+	"path/filepath"
 	"strings"
 
+	"github.com/philippgille/hello-go/count"
+	"github.com/philippgille/hello-go/fib"
+	"github.com/philippgille/hello-go/geo"
+	"github.com/philippgille/hello-go/picture"
 	"golang.org/x/tour/pic"
 	"golang.org/x/tour/wc"
 )
@@ -281,6 +288,31 @@ func mapLiterals2() {
 	fmt.Println(m)
 }
 
+// vertex2 can be converted to geo.Location, because both structs have the same fields
+func (v vertex2) location() geo.Location {
+	return geo.Location(v)
+}
+
+// Calculations with the "geo" package
+func myGeo() {
+	var m = map[string]vertex2{
+		"Bell Labs": {40.68433, -74.39967},
+		"Google":    {37.42202, -122.08408},
+	}
+	bellLabs, google := m["Bell Labs"].location(), m["Google"].location()
+	fmt.Printf("%.0f km\n", geo.Haversine(bellLabs, google)/1000)
+	fmt.Printf("%.0f°\n", geo.Bearing(bellLabs, google))
+	fmt.Println(geo.Midpoint(bellLabs, google))
+
+	places := make(map[string]geo.Location)
+	for name, v := range m {
+		places[name] = v.location()
+	}
+	fmt.Println(geo.Nearest(geo.Location{Lat: 40.7128, Long: -74.0060}, places)) // New York
+	geoJSON, _ := geo.MarshalFeatureCollection(places)
+	fmt.Println(string(geoJSON))
+}
+
 // Mutating maps
 func mutatingMaps() {
 	m := make(map[string]int)
@@ -298,14 +330,19 @@ func mutatingMaps() {
 	fmt.Println("The value:", v, "Present?", ok)
 }
 
-// WordCount is an exercise
+// WordCount is an exercise.
+// The first solution was a loop over strings.Split(s, " "), which counts "" for double spaces and doesn't split at tabs and newlines.
+// Now it uses the "count" package, which splits like strings.Fields (the hint of the exercise) at any Unicode white space,
+// so "a\u00a0b" (with a no-break space) are two words instead of one.
 func WordCount(s string) map[string]int {
-	result := make(map[string]int)
-	words := strings.Split(s, " ")
-	for _, word := range words {
-		result[word] = result[word] + 1
-	}
-	return result
+	return count.Words(s)
+}
+
+// The "count" package generalizes WordCount, and also counts characters and letters
+func myCount() {
+	fmt.Println(count.Words("I am learning  Go!\tGo is fun")) // map[Go:1 Go!:1 I:1 am:1 fun:1 is:1 learning:1]
+	fmt.Println(count.Runes("Gö Go")[' '])                    // 1
+	fmt.Println(count.Letters("Hello, Go!")['o'-'a'])         // 2
 }
 
 // Functions are values, too
@@ -343,16 +380,10 @@ func myClosure() {
 }
 
 // Exercise
-// fibonacci is a function that returns a function that returns an int.
-func fibonacci() func() int {
-	n := 0
-	m := 1
-	return func() int {
-		result := n + m
-		n = m
-		m = result
-		return result
-	}
+// fibonacci is a function that returns a function that returns the next Fibonacci number, starting with 0.
+// It's a *big.Int instead of an int, because int overflows after the 92nd value.
+func fibonacci() func() *big.Int {
+	return fib.Closure()
 }
 func fibTester() {
 	f := fibonacci()
@@ -412,6 +443,13 @@ func main() {
 	myRange2()
 
 	pic.Show(Pic)
+	// pic.Show is only meant for the Tour of Go website, the "picture" package saves images as files
+	path := filepath.Join(os.TempDir(), "pic.gif")
+	if err := picture.Save(path, picture.FromPic(Pic, 256, 256)); err != nil {
+		fmt.Println(err)
+	} else {
+		fmt.Println("Saved image to", path)
+	}
 
 	myMap()
 
@@ -419,9 +457,12 @@ func main() {
 
 	mapLiterals2()
 
+	myGeo()
+
 	mutatingMaps()
 
 	wc.Test(WordCount)
+	myCount()
 
 	functionValues()
 
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestWordCount(t *testing.T) {
+	tests := []struct {
+		s    string
+		want map[string]int
+	}{
+		{"I am learning Go!", map[string]int{"I": 1, "am": 1, "learning": 1, "Go!": 1}},
+		{"go  go\tgo\ngo ", map[string]int{"go": 4}},
+		{"", map[string]int{}},
+		{" \t ", map[string]int{}},
+		// Like strings.Fields, Unicode white space separates words, too
+		{"a\u00a0b\u3000c\u2028a", map[string]int{"a": 2, "b": 1, "c": 1}},
+		{"Gö, Go!\r\nGö,", map[string]int{"Gö,": 2, "Go!": 1}},
+	}
+	for _, test := range tests {
+		if got := WordCount(test.s); !maps.Equal(got, test.want) {
+			t.Errorf("WordCount(%q) = %v, want %v", test.s, got, test.want)
+		}
+	}
+}
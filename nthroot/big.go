@@ -0,0 +1,85 @@
+package nthroot
+
+import (
+	"errors"
+	"math"
+	"math/big"
+
+	"github.com/philippgille/hello-go/errs"
+)
+
+// guardBits are calculated in addition to the requested precision, so the result is correctly rounded
+const guardBits = 32
+
+// ErrPrecision is returned by BigSqrt for a precision of 0, which big.Float only uses for values without mantissa, like 0 and Inf.
+var ErrPrecision = errors.New("nthroot: precision must be at least 1 bit")
+
+// BigSqrt returns the square root of x with prec bits of mantissa, or ErrNegative if x is negative and ErrPrecision if prec is 0.
+// It uses Newton's method like "MySqrt", with z = (z + x/z) / 2, which doubles the number of correct bits in each step.
+// The iteration stops when a step changes z by less than the precision, so the result is correctly rounded.
+func BigSqrt(x *big.Float, prec uint) (*big.Float, error) {
+	switch {
+	case prec == 0:
+		return nil, errs.Wrap(ErrPrecision, errs.InvalidArgument, "", "prec", prec)
+	case x.Sign() < 0:
+		return nil, errs.Wrap(ErrNegative, errs.InvalidArgument, "", "x", x.String())
+	case x.Sign() == 0 || x.IsInf():
+		return new(big.Float).SetPrec(prec).Set(x), nil // Keeps the sign of -0
+	}
+
+	work := prec + guardBits
+	x = new(big.Float).SetPrec(work).Set(x)
+
+	// Start with the float64 root of the mantissa, scaled by half of the exponent.
+	// That's already correct to about 50 bits, and it works for values outside of the float64 range.
+	mant := new(big.Float)
+	exp := x.MantExp(mant) // x = mant * 2^exp, 0.5 <= mant < 1
+	m, _ := mant.Float64()
+	if exp%2 != 0 {
+		m *= 2
+		exp--
+	}
+	z := new(big.Float).SetPrec(work).SetFloat64(math.Sqrt(m))
+	z.SetMantExp(z, exp/2)
+
+	quo := new(big.Float).SetPrec(work)
+	diff := new(big.Float).SetPrec(work)
+	for {
+		quo.Quo(x, z)
+		diff.Sub(quo, z)
+		z.Add(z, quo)
+		z.SetMantExp(z, -1) // Division by 2
+		// The error after a step is about the square of the step, so once the step is below the requested precision,
+		// z is correct to the working precision. Waiting for a step of 0 could take forever, because of rounding.
+		if diff.Sign() == 0 || diff.MantExp(nil) < z.MantExp(nil)-int(prec)-guardBits/2 {
+			break
+		}
+	}
+	return new(big.Float).SetPrec(prec).Set(z), nil
+}
+
+// IntSqrt returns the largest integer whose square is at most x, and whether its square is exactly x (x is a perfect square).
+// It returns ErrNegative if x is negative.
+func IntSqrt(x *big.Int) (root *big.Int, exact bool, err error) {
+	if x.Sign() < 0 {
+		return nil, false, errs.Wrap(ErrNegative, errs.InvalidArgument, "", "x", x.String())
+	}
+	if x.Sign() == 0 {
+		return new(big.Int), true, nil
+	}
+
+	// Newton's method with integer division, starting above the root,
+	// so z decreases in each step until it's the floor of the root
+	z := new(big.Int).Lsh(big.NewInt(1), uint(x.BitLen()+1)/2)
+	y := new(big.Int)
+	for {
+		y.Quo(x, z)
+		y.Add(y, z)
+		y.Rsh(y, 1)
+		if y.Cmp(z) >= 0 {
+			break
+		}
+		z.Set(y)
+	}
+	return z, y.Mul(z, z).Cmp(x) == 0, nil
+}
@@ -0,0 +1,126 @@
+package nthroot
+
+import (
+	"errors"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/philippgille/hello-go/errs"
+)
+
+// bigSqrt is the reference for BigSqrt.
+// big.Float.Sqrt doesn't round exact ties correctly, like the root 0.75 of 0.5625 with a precision of 1 bit, which it rounds down to 0.5.
+// With 64 more bits such roots are exact, so rounding them to prec gives the correct result.
+func bigSqrt(x *big.Float, prec uint) *big.Float {
+	exact := new(big.Float).SetPrec(prec + 64).Sqrt(x)
+	return new(big.Float).SetPrec(prec).Set(exact)
+}
+
+// BigSqrt must give the correctly rounded result for any precision and exponent
+func TestBigSqrt(t *testing.T) {
+	// big.Int.Rand needs math/rand and not math/rand/v2
+	r := rand.New(rand.NewSource(1))
+	for _, prec := range []uint{1, 2, 3, 24, 53, 64, 65, 100, 200, 1000, 4000} {
+		for i := 0; i < 100; i++ {
+			// Random mantissa with up to twice the precision, so x itself isn't always exact in prec bits
+			bits := 1 + r.Intn(2*int(prec)+10)
+			mant := new(big.Int).Rand(r, new(big.Int).Lsh(big.NewInt(1), uint(bits)))
+			mant.SetBit(mant, bits-1, 1)
+			x := new(big.Float).SetPrec(uint(bits)).SetInt(mant)
+			x.SetMantExp(x, r.Intn(20001)-10000)
+
+			got, err := BigSqrt(x, prec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := bigSqrt(x, prec)
+			if got.Cmp(want) != 0 || got.Prec() != prec {
+				t.Fatalf("BigSqrt(%v, %v) = %v (prec %v), want %v", x.Text('p', 0), prec, got.Text('p', 0), got.Prec(), want.Text('p', 0))
+			}
+		}
+	}
+
+	// Values outside of the float64 range
+	for _, s := range []string{"1e-400", "1e400", "1e100000", "2"} {
+		x, _, _ := big.ParseFloat(s, 10, 500, big.ToNearestEven)
+		got, _ := BigSqrt(x, 300)
+		if want := bigSqrt(x, 300); got.Cmp(want) != 0 {
+			t.Errorf("BigSqrt(%v) = %v, want %v", s, got, want)
+		}
+	}
+}
+
+func TestBigSqrtSpecial(t *testing.T) {
+	negZero := new(big.Float).Neg(new(big.Float))
+	for _, x := range []*big.Float{new(big.Float), negZero, new(big.Float).SetInf(false)} {
+		got, err := BigSqrt(x, 100)
+		if err != nil || got.Cmp(x) != 0 || got.Signbit() != x.Signbit() || got.IsInf() != x.IsInf() {
+			t.Errorf("BigSqrt(%v) = %v, %v, want %v", x, got, err, x)
+		}
+	}
+	for _, x := range []*big.Float{big.NewFloat(-1), new(big.Float).SetInf(true)} {
+		if _, err := BigSqrt(x, 100); !errors.Is(err, ErrNegative) || !errors.Is(err, errs.InvalidArgument) {
+			t.Errorf("BigSqrt(%v) = %v, want %v", x, err, ErrNegative)
+		}
+	}
+	for _, x := range []*big.Float{big.NewFloat(2), new(big.Float), big.NewFloat(-1)} {
+		if got, err := BigSqrt(x, 0); !errors.Is(err, ErrPrecision) || !errors.Is(err, errs.InvalidArgument) || got != nil {
+			t.Errorf("BigSqrt(%v, 0) = %v, %v, want %v", x, got, err, ErrPrecision)
+		}
+	}
+}
+
+func TestIntSqrt(t *testing.T) {
+	tests := []struct {
+		x, root int64
+		exact   bool
+	}{
+		{0, 0, true},
+		{1, 1, true},
+		{2, 1, false},
+		{3, 1, false},
+		{4, 2, true},
+		{15, 3, false},
+		{16, 4, true},
+		{17, 4, false},
+		{1 << 40, 1 << 20, true},
+		{1<<62 - 1, 1<<31 - 1, false},
+	}
+	for _, test := range tests {
+		root, exact, err := IntSqrt(big.NewInt(test.x))
+		if err != nil || root.Int64() != test.root || exact != test.exact {
+			t.Errorf("IntSqrt(%v) = %v, %v, %v, want %v, %v", test.x, root, exact, err, test.root, test.exact)
+		}
+	}
+	if _, _, err := IntSqrt(big.NewInt(-1)); !errors.Is(err, ErrNegative) {
+		t.Errorf("IntSqrt(-1) = %v, want %v", err, ErrNegative)
+	}
+
+	// Perfect squares and their neighbors up to 4000 bits
+	r := rand.New(rand.NewSource(2))
+	for i := 0; i < 200; i++ {
+		n := new(big.Int).Rand(r, new(big.Int).Lsh(big.NewInt(1), uint(1+r.Intn(2000))))
+		n.Add(n, big.NewInt(2))
+		square := new(big.Int).Mul(n, n)
+		neighbors := []struct {
+			x     *big.Int
+			root  *big.Int
+			exact bool
+		}{
+			{square, n, true},
+			{new(big.Int).Sub(square, big.NewInt(1)), new(big.Int).Sub(n, big.NewInt(1)), false},
+			{new(big.Int).Add(square, new(big.Int).Lsh(n, 1)), n, false}, // (n+1)² - 1
+		}
+		for _, test := range neighbors {
+			root, exact, err := IntSqrt(test.x)
+			if err != nil || root.Cmp(test.root) != 0 || exact != test.exact {
+				t.Fatalf("IntSqrt(%v) = %v, %v, %v, want %v, %v", test.x, root, exact, err, test.root, test.exact)
+			}
+		}
+		// Same as big.Int.Sqrt
+		if root, _, _ := IntSqrt(n); root.Cmp(new(big.Int).Sqrt(n)) != 0 {
+			t.Fatalf("IntSqrt(%v) = %v, big.Int.Sqrt: %v", n, root, new(big.Int).Sqrt(n))
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Package nthroot calculates square roots and nth roots, like "sqrt" in the flowcontrol chapter and "mySqrt" in the methods chapter.
+//
+// There are two modes:
+// Sqrt and Root return real roots and an error for even roots of negative numbers,
+// ComplexSqrt, ComplexRoot and ComplexRoots return complex roots for any input.
+//
+// Special values are handled the same way in both modes:
+// NaN leads to NaN, +Inf to +Inf, and the root of -0 is -0 (because -0 isn't negative).
+package nthroot
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+
+	"github.com/philippgille/hello-go/errs"
+)
+
+// ErrNegative is returned by Sqrt and Root for even roots of negative numbers, which aren't real numbers.
+// The returned errors also match errs.InvalidArgument with errors.Is.
+var ErrNegative = errors.New("nthroot: even root of negative number")
+
+// ErrDegree is returned by Root for n < 1.
+var ErrDegree = errors.New("nthroot: degree must be at least 1")
+
+// Sqrt returns the square root of x, or ErrNegative if x is negative (including -Inf).
+func Sqrt(x float64) (float64, error) {
+	return Root(x, 2)
+}
+
+// Root returns the real nth root of x.
+// For odd n negative numbers have a real root, like Root(-8, 3) = -2.
+// For even n and negative x the error is ErrNegative.
+func Root(x float64, n int) (float64, error) {
+	switch {
+	case n < 1:
+		return math.NaN(), errs.Wrap(ErrDegree, errs.InvalidArgument, "", "n", n)
+	case math.IsNaN(x) || x == 0:
+		return x, nil // Keeps the sign of -0
+	case x < 0:
+		if n%2 == 0 {
+			return math.NaN(), errs.Wrap(ErrNegative, errs.InvalidArgument, "", "x", x, "n", n)
+		}
+		return -root(-x, n), nil
+	}
+	return root(x, n), nil
+}
+
+// root returns the nth root of a positive x (including +Inf)
+func root(x float64, n int) float64 {
+	switch {
+	case n == 1 || math.IsInf(x, 1):
+		return x
+	case n == 2:
+		return math.Sqrt(x)
+	case n == 3:
+		return math.Cbrt(x)
+	}
+	y := math.Pow(x, 1/float64(n))
+	// 1/n isn't exact, so one Newton step corrects the last digits, like in "MySqrt".
+	// It's skipped if y^n overflows.
+	if step := (math.Pow(y, float64(n)) - x) / (float64(n) * math.Pow(y, float64(n-1))); !math.IsNaN(step) && !math.IsInf(step, 0) {
+		y -= step
+	}
+	return y
+}
+
+// ========
+
+// ComplexSqrt returns the principal square root of x, which is imaginary for negative x,
+// like ComplexSqrt(-4) = 2i.
+func ComplexSqrt(x float64) complex128 {
+	return ComplexRoot(complex(x, 0), 2)
+}
+
+// ComplexRoot returns the principal nth root of z, which is the one with the smallest angle counterclockwise from the positive real axis.
+// For negative real numbers and odd n it's not the real root, for example ComplexRoot(-8, 3) = 1+1.732i instead of -2.
+// It returns NaN for n < 1.
+func ComplexRoot(z complex128, n int) complex128 {
+	if n < 1 {
+		return cmplx.NaN()
+	}
+	return ComplexRoots(z, n)[0]
+}
+
+// ComplexRoots returns all n nth roots of z, starting with the principal root and going counterclockwise.
+// The roots of 0 are n times z, and if z has a NaN part, they're n times NaN.
+// It returns nil for n < 1.
+func ComplexRoots(z complex128, n int) []complex128 {
+	if n < 1 {
+		return nil
+	}
+	roots := make([]complex128, n)
+	if cmplx.IsNaN(z) || z == 0 {
+		if cmplx.IsNaN(z) {
+			z = cmplx.NaN()
+		}
+		for k := range roots {
+			roots[k] = z // Keeps the sign of -0
+		}
+		return roots
+	}
+
+	r := root(cmplx.Abs(z), n)
+	phase := cmplx.Phase(z)
+	for k := range roots {
+		roots[k] = polar(r, (phase+2*math.Pi*float64(k))/float64(n))
+	}
+	return roots
+}
+
+// polar is like cmplx.Rect, but sine and cosine values below the rounding error are 0,
+// so the square root of -4 is exactly 2i and not 1.2e-16+2i.
+// This also prevents Inf*0 = NaN for infinite r.
+func polar(r, theta float64) complex128 {
+	sin, cos := math.Sincos(theta)
+	return complex(scale(r, cos), scale(r, sin))
+}
+
+func scale(r, f float64) float64 {
+	if math.Abs(f) < 1e-15 {
+		return 0
+	}
+	return r * f
+}
@@ -0,0 +1,175 @@
+package nthroot
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"github.com/philippgille/hello-go/errs"
+)
+
+var (
+	inf    = math.Inf(1)
+	nan    = math.NaN()
+	negZer = math.Copysign(0, -1)
+)
+
+// same reports whether a and b are the same value, with NaN equal to NaN and -0 different from +0
+func same(a, b float64) bool {
+	if math.IsNaN(a) || math.IsNaN(b) {
+		return math.IsNaN(a) && math.IsNaN(b)
+	}
+	return a == b && math.Signbit(a) == math.Signbit(b)
+}
+
+func sameComplex(a, b complex128) bool {
+	return same(real(a), real(b)) && same(imag(a), imag(b))
+}
+
+// The special values of both modes, see the package documentation
+func TestSpecialValues(t *testing.T) {
+	tests := []struct {
+		x    float64
+		n    int
+		want float64 // Real mode
+		err  error
+		c    complex128 // Complex mode
+	}{
+		{nan, 1, nan, nil, cmplx.NaN()},
+		{nan, 2, nan, nil, cmplx.NaN()},
+		{nan, 3, nan, nil, cmplx.NaN()},
+		{inf, 1, inf, nil, complex(inf, 0)},
+		{inf, 2, inf, nil, complex(inf, 0)},
+		{inf, 3, inf, nil, complex(inf, 0)},
+		{inf, 4, inf, nil, complex(inf, 0)},
+		{-inf, 1, -inf, nil, complex(-inf, 0)},
+		{-inf, 2, nan, ErrNegative, complex(0, inf)},
+		{-inf, 3, -inf, nil, complex(inf, inf)},
+		{-inf, 4, nan, ErrNegative, complex(inf, inf)},
+		{0, 1, 0, nil, 0},
+		{0, 2, 0, nil, 0},
+		{0, 3, 0, nil, 0},
+		{negZer, 1, negZer, nil, complex(negZer, 0)},
+		{negZer, 2, negZer, nil, complex(negZer, 0)},
+		{negZer, 3, negZer, nil, complex(negZer, 0)},
+		{negZer, 4, negZer, nil, complex(negZer, 0)},
+		{4, 2, 2, nil, 2},
+		{-4, 2, nan, ErrNegative, 2i},
+		{-8, 3, -2, nil, complex(1, math.Sqrt(3))},
+		{-1, 1, -1, nil, -1},
+		{16, 4, 2, nil, 2},
+		{-16, 4, nan, ErrNegative, complex(math.Sqrt2, math.Sqrt2)},
+		{1, 0, nan, ErrDegree, cmplx.NaN()},
+		{nan, -1, nan, ErrDegree, cmplx.NaN()},
+	}
+	for _, test := range tests {
+		got, err := Root(test.x, test.n)
+		if !errors.Is(err, test.err) || (err == nil) != (test.err == nil) {
+			t.Errorf("Root(%v, %v) error = %v, want %v", test.x, test.n, err, test.err)
+		}
+		if err != nil && !errors.Is(err, errs.InvalidArgument) {
+			t.Errorf("Root(%v, %v) error %v doesn't match errs.InvalidArgument", test.x, test.n, err)
+		}
+		if !same(got, test.want) {
+			t.Errorf("Root(%v, %v) = %v, want %v", test.x, test.n, got, test.want)
+		}
+		if test.n == 2 {
+			if got, err := Sqrt(test.x); !same(got, test.want) || !errors.Is(err, test.err) {
+				t.Errorf("Sqrt(%v) = %v, %v, want %v, %v", test.x, got, err, test.want, test.err)
+			}
+			if got := ComplexSqrt(test.x); !sameComplex(got, test.c) {
+				t.Errorf("ComplexSqrt(%v) = %v, want %v", test.x, got, test.c)
+			}
+		}
+
+		c := ComplexRoot(complex(test.x, 0), test.n)
+		if cmplx.IsNaN(test.c) {
+			if !cmplx.IsNaN(c) {
+				t.Errorf("ComplexRoot(%v, %v) = %v, want NaN", test.x, test.n, c)
+			}
+			continue
+		}
+		if math.IsInf(real(test.c), 0) || math.IsInf(imag(test.c), 0) || test.c == 0 {
+			// Exact values, including the sign of 0
+			if !sameComplex(c, test.c) {
+				t.Errorf("ComplexRoot(%v, %v) = %v, want %v", test.x, test.n, c, test.c)
+			}
+		} else if cmplx.Abs(c-test.c) > 1e-15 {
+			t.Errorf("ComplexRoot(%v, %v) = %v, want %v", test.x, test.n, c, test.c)
+		}
+	}
+}
+
+func TestRoot(t *testing.T) {
+	tests := []struct {
+		x    float64
+		n    int
+		want float64
+	}{
+		{2, 2, math.Sqrt2},
+		{27, 3, 3},
+		{-27, 3, -3},
+		{1024, 10, 2},
+		{-1024, 5, -4},
+		{math.Pow(2, 100), 100, 2},
+		{1e300, 3, 1e100},
+		{1e300, 300, 10},
+		{math.MaxFloat64, 2, math.Sqrt(math.MaxFloat64)},
+		{math.SmallestNonzeroFloat64, 1, math.SmallestNonzeroFloat64},
+		{7, 1, 7},
+	}
+	for _, test := range tests {
+		got, err := Root(test.x, test.n)
+		if err != nil {
+			t.Errorf("Root(%v, %v) = %v", test.x, test.n, err)
+			continue
+		}
+		if math.Abs(got-test.want) > 4e-16*math.Abs(test.want) {
+			t.Errorf("Root(%v, %v) = %v, want %v", test.x, test.n, got, test.want)
+		}
+	}
+}
+
+func TestComplexRoots(t *testing.T) {
+	if roots := ComplexRoots(1, 0); roots != nil {
+		t.Errorf("ComplexRoots(1, 0) = %v, want nil", roots)
+	}
+	// The fourth roots of 1 are exact, starting with 1, counterclockwise
+	want := []complex128{1, 1i, -1, -1i}
+	for i, r := range ComplexRoots(1, 4) {
+		if r != want[i] {
+			t.Errorf("root %v = %v, want %v", i, r, want[i])
+		}
+	}
+
+	// Each of the roots to the power of n is z again
+	for _, z := range []complex128{3 + 4i, -2, 1i, -5 - 1e-3i, 1e100 + 1e100i} {
+		for n := 1; n <= 7; n++ {
+			roots := ComplexRoots(z, n)
+			if len(roots) != n {
+				t.Fatalf("ComplexRoots(%v, %v) returned %v roots", z, n, len(roots))
+			}
+			for k, r := range roots {
+				if p := cmplx.Pow(r, complex(float64(n), 0)); cmplx.Abs(p-z) > 1e-12*cmplx.Abs(z) {
+					t.Errorf("root %v of ComplexRoots(%v, %v) = %v, but %v^%v = %v", k, z, n, r, r, n, p)
+				}
+				// All roots are different
+				for _, o := range roots[:k] {
+					if cmplx.Abs(o-r) < 1e-9*cmplx.Abs(r) {
+						t.Errorf("ComplexRoots(%v, %v) has %v twice", z, n, r)
+					}
+				}
+			}
+		}
+	}
+
+	// The roots of 0 and NaN are all the same
+	for _, z := range []complex128{0, complex(negZer, 0), cmplx.NaN(), complex(1, nan)} {
+		for _, r := range ComplexRoots(z, 3) {
+			if cmplx.IsNaN(z) != cmplx.IsNaN(r) || (!cmplx.IsNaN(z) && !sameComplex(r, z)) {
+				t.Errorf("ComplexRoots(%v, 3) contains %v", z, r)
+			}
+		}
+	}
+}
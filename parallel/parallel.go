@@ -0,0 +1,142 @@
+// Package parallel provides reductions over slices that split the work across multiple goroutines,
+// like "myChannel" in the concurrency chapter does with two halves.
+package parallel
+
+import (
+	"runtime"
+	"sync"
+)
+
+// Number is any integer or floating point type.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// Sum adds up all values of s with the given number of workers.
+// If workers is 0 or less, runtime.GOMAXPROCS(0) workers are used.
+func Sum[T Number](s []T, workers int) T {
+	// Like Reduce, but with a plain loop instead of calling a function for every value, which is considerably faster (see BenchmarkSum and BenchmarkReduce)
+	chunks := split(len(s), workers)
+	results := make([]T, len(chunks))
+	var wg sync.WaitGroup
+	for i, c := range chunks {
+		wg.Add(1)
+		go func(i int, c []T) {
+			defer wg.Done()
+			results[i] = sum(c)
+		}(i, s[c.from:c.to])
+	}
+	wg.Wait()
+	return sum(results)
+}
+
+// Reduce combines all values of s with op, which must be associative.
+// identity must be the identity element of op (like 0 for + or 1 for *).
+// The slice is split into one chunk per worker.
+// If workers is 0 or less, runtime.GOMAXPROCS(0) workers are used.
+func Reduce[T any](s []T, identity T, op func(a, b T) T, workers int) T {
+	chunks := split(len(s), workers)
+	results := make([]T, len(chunks))
+	var wg sync.WaitGroup
+	for i, c := range chunks {
+		wg.Add(1)
+		go func(i int, c []T) {
+			defer wg.Done()
+			results[i] = reduce(c, identity, op)
+		}(i, s[c.from:c.to])
+	}
+	wg.Wait()
+	// The chunk results are combined in order, so op doesn't have to be commutative
+	return reduce(results, identity, op)
+}
+
+// Scan returns the inclusive prefix "sums" of s,
+// so the i-th result is the combination of s[0] to s[i] with op, which must be associative.
+// If workers is 0 or less, runtime.GOMAXPROCS(0) workers are used.
+func Scan[T any](s []T, identity T, op func(a, b T) T, workers int) []T {
+	result := make([]T, len(s))
+	chunks := split(len(s), workers)
+
+	// 1. Scan each chunk on its own
+	totals := make([]T, len(chunks))
+	var wg sync.WaitGroup
+	for i, c := range chunks {
+		wg.Add(1)
+		go func(i int, c chunk) {
+			defer wg.Done()
+			acc := identity
+			for j := c.from; j < c.to; j++ {
+				acc = op(acc, s[j])
+				result[j] = acc
+			}
+			totals[i] = acc
+		}(i, c)
+	}
+	wg.Wait()
+
+	// 2. Each chunk (except the first) is offset by the totals of all previous chunks
+	offset := identity
+	for i, c := range chunks {
+		if i > 0 {
+			wg.Add(1)
+			go func(c chunk, offset T) {
+				defer wg.Done()
+				for j := c.from; j < c.to; j++ {
+					result[j] = op(offset, result[j])
+				}
+			}(c, offset)
+		}
+		offset = op(offset, totals[i])
+	}
+	wg.Wait()
+	return result
+}
+
+// ========
+
+// chunk is the half-open range [from, to) of a slice
+type chunk struct {
+	from, to int
+}
+
+// split divides n elements into at most "workers" chunks of (almost) equal size
+func split(n, workers int) []chunk {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > n {
+		workers = n
+	}
+	chunks := make([]chunk, workers)
+	from := 0
+	for i := range chunks {
+		// Distribute the remainder of n/workers across the first chunks
+		size := n / workers
+		if i < n%workers {
+			size++
+		}
+		chunks[i] = chunk{from, from + size}
+		from += size
+	}
+	return chunks
+}
+
+// sum is the sequential sum of s
+func sum[T Number](s []T) T {
+	var acc T
+	for _, v := range s {
+		acc += v
+	}
+	return acc
+}
+
+// reduce is the sequential reduction of s
+func reduce[T any](s []T, identity T, op func(a, b T) T) T {
+	acc := identity
+	for _, v := range s {
+		acc = op(acc, v)
+	}
+	return acc
+}
@@ -0,0 +1,115 @@
+package parallel
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func concat(a, b string) string {
+	return a + b
+}
+
+func TestSum(t *testing.T) {
+	s := make([]int, 1001)
+	for i := range s {
+		s[i] = i
+	}
+	for _, workers := range []int{0, 1, 3, 1000, 2000} {
+		if got := Sum(s, workers); got != 500500 {
+			t.Errorf("Sum(0..1000, %v) = %v, want 500500", workers, got)
+		}
+	}
+	if got := Sum([]float64(nil), 4); got != 0 {
+		t.Errorf("Sum(nil) = %v, want 0", got)
+	}
+}
+
+// Concatenation is associative, but not commutative, so the chunks must be combined in order
+func TestReduceNonCommutative(t *testing.T) {
+	s := strings.Split("abcdefghijklmnopqrstuvwxyz", "")
+	for _, workers := range []int{0, 1, 4, 26, 100} {
+		if got := Reduce(s, "", concat, workers); got != "abcdefghijklmnopqrstuvwxyz" {
+			t.Errorf("Reduce(%v workers) = %q", workers, got)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	add := func(a, b int) int { return a + b }
+	tests := []struct {
+		s       []int
+		workers int
+		want    []int
+	}{
+		{nil, 4, []int{}},
+		{[]int{}, 0, []int{}},
+		{[]int{5}, 4, []int{5}},
+		{[]int{7, 2, 8, -9, 4, 0}, 1, []int{7, 9, 17, 8, 12, 12}},
+		{[]int{7, 2, 8, -9, 4, 0}, 2, []int{7, 9, 17, 8, 12, 12}},
+		{[]int{7, 2, 8, -9, 4, 0}, 4, []int{7, 9, 17, 8, 12, 12}},
+		// More workers than values
+		{[]int{7, 2, 8, -9, 4, 0}, 100, []int{7, 9, 17, 8, 12, 12}},
+	}
+	for _, test := range tests {
+		if got := Scan(test.s, 0, add, test.workers); !slices.Equal(got, test.want) {
+			t.Errorf("Scan(%v, %v workers) = %v, want %v", test.s, test.workers, got, test.want)
+		}
+	}
+}
+
+func TestScanNonCommutative(t *testing.T) {
+	s := strings.Split("abcdefghij", "")
+	want := []string{"a", "ab", "abc", "abcd", "abcde", "abcdef", "abcdefg", "abcdefgh", "abcdefghi", "abcdefghij"}
+	for _, workers := range []int{0, 1, 3, 10, 20} {
+		if got := Scan(s, "", concat, workers); !slices.Equal(got, want) {
+			t.Errorf("Scan(%v workers) = %q, want %q", workers, got, want)
+		}
+	}
+}
+
+// ========
+
+func benchmarkData() []float64 {
+	s := make([]float64, 10000000)
+	for i := range s {
+		s[i] = float64(i)
+	}
+	return s
+}
+
+func BenchmarkSum(b *testing.B) {
+	s := benchmarkData()
+	for _, workers := range []int{1, 0} {
+		b.Run(fmt.Sprint("workers=", workers), func(b *testing.B) {
+			for b.Loop() {
+				Sum(s, workers)
+			}
+		})
+	}
+}
+
+func BenchmarkReduce(b *testing.B) {
+	s := benchmarkData()
+	add := func(a, b float64) float64 { return a + b }
+	for _, workers := range []int{1, 0} {
+		b.Run(fmt.Sprint("workers=", workers), func(b *testing.B) {
+			for b.Loop() {
+				Reduce(s, 0, add, workers)
+			}
+		})
+	}
+}
+
+func BenchmarkScan(b *testing.B) {
+	s := benchmarkData()
+	add := func(a, b float64) float64 { return a + b }
+	for _, workers := range []int{1, 0} {
+		b.Run(fmt.Sprint("workers=", workers), func(b *testing.B) {
+			for b.Loop() {
+				Scan(s, 0, add, workers)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+// Package picture renders images like "Pic" in the moretypes chapter and "myImage" in the methods chapter
+// and saves them as PNG, JPEG or GIF, instead of the base64 output of pic.Show and pic.ShowImage, which is only meant for the Tour of Go website.
+package picture
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Formula returns the value of the pixel at x, y, which is rendered like pic.Show does it:
+// as color {v, v, 255}, so 0 is blue and 255 is white.
+type Formula func(x, y int) uint8
+
+// Formulas are some formulas that lead to interesting pictures, by name.
+var Formulas = map[string]Formula{
+	"avg": func(x, y int) uint8 { return uint8((x + y) / 2) },
+	"mul": func(x, y int) uint8 { return uint8(x * y) },
+	"xor": func(x, y int) uint8 { return uint8(x ^ y) },
+	"and": func(x, y int) uint8 { return uint8(x & y) },
+	"mod": func(x, y int) uint8 { return uint8(x * y % (x + y + 1)) },
+}
+
+// FormulaNames returns the names of Formulas, sorted.
+func FormulaNames() []string {
+	names := make([]string, 0, len(Formulas))
+	for name := range Formulas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// formulaImage implements image.Image, like "myImage" does, but for any formula and size
+type formulaImage struct {
+	f    Formula
+	w, h int
+}
+
+// FromFormula returns an image of the size that calculates its pixels with f.
+// The pixels are calculated when they're accessed, so the image doesn't need memory.
+func FromFormula(f Formula, w, h int) image.Image {
+	return formulaImage{f, w, h}
+}
+
+func (img formulaImage) ColorModel() color.Model {
+	return color.RGBAModel
+}
+
+func (img formulaImage) Bounds() image.Rectangle {
+	return image.Rect(0, 0, img.w, img.h)
+}
+
+func (img formulaImage) At(x, y int) color.Color {
+	return blue(img.f(x, y))
+}
+
+// PicFunc returns a function like "Pic" for the formula, which can be passed to pic.Show or FromPic.
+func PicFunc(f Formula) func(dx, dy int) [][]uint8 {
+	return func(dx, dy int) [][]uint8 {
+		result := make([][]uint8, dy)
+		for y := range result {
+			result[y] = make([]uint8, dx)
+			for x := range result[y] {
+				result[y][x] = f(x, y)
+			}
+		}
+		return result
+	}
+}
+
+// FromPic returns the image of a function like "Pic", with the size dx * dy, rendered like pic.Show.
+// Like pic.Show it expects dy rows of dx values. Missing values are 0.
+func FromPic(f func(dx, dy int) [][]uint8, dx, dy int) image.Image {
+	data := f(dx, dy)
+	img := image.NewRGBA(image.Rect(0, 0, dx, dy))
+	for y := 0; y < dy; y++ {
+		for x := 0; x < dx; x++ {
+			var v uint8
+			if y < len(data) && x < len(data[y]) {
+				v = data[y][x]
+			}
+			img.SetRGBA(x, y, blue(v))
+		}
+	}
+	return img
+}
+
+func blue(v uint8) color.RGBA {
+	return color.RGBA{v, v, 255, 255}
+}
+
+// ========
+
+// Formats are the supported image formats.
+var Formats = []string{"png", "jpeg", "gif"}
+
+// Encode writes the image in the format ("png", "jpeg" or "gif").
+// JPEG uses quality 90, GIF the Plan 9 palette with dithering.
+func Encode(w io.Writer, img image.Image, format string) error {
+	if err := checkFormat(format); err != nil {
+		return err
+	}
+	switch format {
+	case "png":
+		return png.Encode(w, img)
+	case "jpeg", "jpg":
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: 90})
+	}
+	return gif.Encode(w, img, nil)
+}
+
+func checkFormat(format string) error {
+	switch format {
+	case "png", "jpeg", "jpg", "gif":
+		return nil
+	}
+	return fmt.Errorf("picture: unknown format %q, must be one of %v", format, Formats)
+}
+
+// Save writes the image to the file, in the format of its extension (".png", ".jpg", ".jpeg" or ".gif").
+func Save(path string, img image.Image) error {
+	return SaveAs(path, img, strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), "."))
+}
+
+// SaveAs writes the image to the file in the format, no matter what the extension is.
+// If encoding or writing fails, the file is removed, so no truncated image is left behind.
+func SaveAs(path string, img image.Image, format string) error {
+	// Check the format first, so no file is created for unknown formats
+	if err := checkFormat(format); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := Encode(f, img, format); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	// Some file systems, like NFS, only report failed writes when the file is closed
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return err
+	}
+	return nil
+}
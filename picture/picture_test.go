@@ -0,0 +1,178 @@
+package picture
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// diff returns the average difference per channel between the pixels of two images of the same size, between 0 and 255
+func diff(a, b image.Image) float64 {
+	var sum, n float64
+	r := a.Bounds()
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			c1 := color.RGBAModel.Convert(a.At(x, y)).(color.RGBA)
+			c2 := color.RGBAModel.Convert(b.At(x, y)).(color.RGBA)
+			for _, d := range []int{
+				int(c1.R) - int(c2.R), int(c1.G) - int(c2.G), int(c1.B) - int(c2.B), int(c1.A) - int(c2.A),
+			} {
+				sum += float64(max(d, -d))
+				n++
+			}
+		}
+	}
+	return sum / n
+}
+
+func TestEncode(t *testing.T) {
+	img := FromFormula(Formulas["xor"], 64, 48)
+	tests := []struct {
+		format    string
+		decodedAs string  // Name returned by image.Decode
+		maxDiff   float64 // PNG is lossless, JPEG and GIF (with 256 colors) aren't
+	}{
+		{"png", "png", 0},
+		{"jpeg", "jpeg", 4},
+		{"jpg", "jpeg", 4},
+		{"gif", "gif", 24}, // Dithering spreads the error to neighbouring pixels
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Encode(&buf, img, tt.format); err != nil {
+				t.Fatal(err)
+			}
+			decoded, name, err := image.Decode(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if name != tt.decodedAs {
+				t.Errorf("decoded as %v, want %v", name, tt.decodedAs)
+			}
+			if decoded.Bounds() != img.Bounds() {
+				t.Fatalf("bounds %v, want %v", decoded.Bounds(), img.Bounds())
+			}
+			if d := diff(img, decoded); d > tt.maxDiff {
+				t.Errorf("average difference %.2f, want at most %v", d, tt.maxDiff)
+			}
+		})
+	}
+
+	// All Formats are supported
+	for _, format := range Formats {
+		if err := Encode(new(bytes.Buffer), img, format); err != nil {
+			t.Errorf("Encode(%v) = %v", format, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, img, "bmp"); err == nil || buf.Len() != 0 {
+		t.Errorf("Encode(bmp) = %v and %v bytes, want an error and nothing written", err, buf.Len())
+	}
+}
+
+func TestSave(t *testing.T) {
+	dir := t.TempDir()
+	img := FromFormula(Formulas["avg"], 32, 32)
+	for _, name := range []string{"a.png", "b.jpg", "c.JPEG", "d.gif"} {
+		path := filepath.Join(dir, name)
+		if err := Save(path, img); err != nil {
+			t.Fatalf("Save(%v) = %v", name, err)
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _, err = image.Decode(f)
+		f.Close()
+		if err != nil {
+			t.Errorf("decoding %v: %v", name, err)
+		}
+	}
+
+	// No file is created for unknown formats
+	for _, name := range []string{"e.bmp", "f"} {
+		path := filepath.Join(dir, name)
+		if err := Save(path, img); err == nil {
+			t.Errorf("Save(%v) didn't fail", name)
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Save(%v) created a file", name)
+		}
+	}
+	if err := Save(filepath.Join(dir, "missing", "g.png"), img); err == nil {
+		t.Error("Save to a missing directory didn't fail")
+	}
+
+	// The file is removed when encoding fails, here because JPEG images are at most 65535 pixels wide
+	for _, name := range []string{"h.jpg", "a.png"} {
+		path := filepath.Join(dir, name)
+		if err := SaveAs(path, FromFormula(Formulas["avg"], 70000, 1), "jpeg"); err == nil {
+			t.Errorf("SaveAs(%v) of a too large JPEG didn't fail", name)
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("SaveAs(%v) left a file after failing", name)
+		}
+	}
+}
+
+func TestSaveAs(t *testing.T) {
+	// The format doesn't depend on the extension
+	path := filepath.Join(t.TempDir(), "picture.png")
+	if err := SaveAs(path, FromFormula(Formulas["mul"], 16, 16), "gif"); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, name, err := image.Decode(f); err != nil || name != "gif" {
+		t.Errorf("decoded as %v, %v, want gif", name, err)
+	}
+	if err := SaveAs(path, FromFormula(Formulas["mul"], 16, 16), "bmp"); err == nil {
+		t.Error("SaveAs(bmp) didn't fail")
+	}
+}
+
+func TestFromPic(t *testing.T) {
+	// FromPic and FromFormula render the same image
+	for _, name := range FormulaNames() {
+		f := Formulas[name]
+		if d := diff(FromFormula(f, 40, 30), FromPic(PicFunc(f), 40, 30)); d != 0 {
+			t.Errorf("%v: average difference %v between FromPic and FromFormula", name, d)
+		}
+	}
+
+	// Missing values are 0, which is blue
+	short := func(dx, dy int) [][]uint8 {
+		return [][]uint8{{255, 255}, {255}}
+	}
+	img := FromPic(short, 3, 3)
+	white, blue := color.RGBA{255, 255, 255, 255}, color.RGBA{0, 0, 255, 255}
+	for _, tt := range []struct {
+		x, y int
+		want color.RGBA
+	}{
+		{0, 0, white}, {1, 0, white}, {2, 0, blue}, {0, 1, white}, {1, 1, blue}, {0, 2, blue},
+	} {
+		if got := img.At(tt.x, tt.y); got != tt.want {
+			t.Errorf("At(%v, %v) = %v, want %v", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
+
+func TestFormulaNames(t *testing.T) {
+	names := FormulaNames()
+	if len(names) != len(Formulas) || !sort.StringsAreSorted(names) {
+		t.Errorf("FormulaNames() = %v, want all %v names sorted", names, len(Formulas))
+	}
+}
@@ -0,0 +1,309 @@
+// Package pipeline provides generic channel pipeline stages
+// (like the "sum" and "fibonacci" examples in the concurrency chapter),
+// which can all be cancelled through a context.
+// The first error returned by any stage cancels the whole pipeline.
+package pipeline
+
+import (
+	"context"
+	"sync"
+)
+
+// Pipeline holds the shared state of all stages that belong to it.
+// Create one with New, connect stages to it and call Wait (or a terminal stage like Sink or Reduce).
+type Pipeline struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	once   sync.Once
+	err    error
+}
+
+// New creates a Pipeline whose stages stop when ctx is done.
+func New(ctx context.Context) *Pipeline {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Pipeline{
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Context returns the context that's shared by all stages.
+// It's done when the pipeline was cancelled or a stage failed.
+func (p *Pipeline) Context() context.Context {
+	return p.ctx
+}
+
+// Cancel stops all stages.
+// Call it when you stop reading from the last channel before it's closed, otherwise the stages block forever.
+func (p *Pipeline) Cancel() {
+	p.once.Do(func() {}) // Use up the "once", so the resulting context error isn't recorded
+	p.cancel()
+}
+
+// Wait blocks until all stages returned and returns the first error.
+// Cancellation via Cancel is not reported as error, but cancellation of the parent context is.
+func (p *Pipeline) Wait() error {
+	p.wg.Wait()
+	p.fail(p.ctx.Err())
+	p.Cancel() // Release the context's resources
+	return p.err
+}
+
+// fail records the first error and cancels all stages
+func (p *Pipeline) fail(err error) {
+	if err == nil {
+		return
+	}
+	p.once.Do(func() {
+		p.err = err
+		p.cancel()
+	})
+}
+
+// run starts a stage in its own goroutine
+func (p *Pipeline) run(f func() error) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.fail(f())
+	}()
+}
+
+// send sends v to out unless the context is done first
+func send[T any](ctx context.Context, out chan<- T, v T) bool {
+	select {
+	case out <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// recv receives a value from in, unless the context is done first.
+// ok is false when in was closed or the context is done.
+func recv[T any](ctx context.Context, in <-chan T) (v T, ok bool) {
+	select {
+	case v, ok = <-in:
+		return v, ok
+	case <-ctx.Done():
+		return v, false
+	}
+}
+
+// ========
+
+// Source emits the given values and then closes the returned channel.
+func Source[T any](p *Pipeline, values ...T) <-chan T {
+	return Generate(p, func(ctx context.Context, emit func(T) bool) error {
+		for _, v := range values {
+			if !emit(v) {
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+// Generate emits all values that gen passes to emit.
+// emit returns false when the pipeline was cancelled, in which case gen should return.
+func Generate[T any](p *Pipeline, gen func(ctx context.Context, emit func(T) bool) error) <-chan T {
+	out := make(chan T)
+	p.run(func() error {
+		defer close(out)
+		return gen(p.ctx, func(v T) bool {
+			return send(p.ctx, out, v)
+		})
+	})
+	return out
+}
+
+// Map applies f to every value of in.
+func Map[T, U any](p *Pipeline, in <-chan T, f func(context.Context, T) (U, error)) <-chan U {
+	out := make(chan U)
+	p.run(func() error {
+		defer close(out)
+		for {
+			v, ok := recv(p.ctx, in)
+			if !ok {
+				return nil
+			}
+			u, err := f(p.ctx, v)
+			if err != nil {
+				return err
+			}
+			if !send(p.ctx, out, u) {
+				return nil
+			}
+		}
+	})
+	return out
+}
+
+// Filter only passes on the values for which keep returns true.
+func Filter[T any](p *Pipeline, in <-chan T, keep func(T) bool) <-chan T {
+	out := make(chan T)
+	p.run(func() error {
+		defer close(out)
+		for {
+			v, ok := recv(p.ctx, in)
+			if !ok {
+				return nil
+			}
+			if keep(v) && !send(p.ctx, out, v) {
+				return nil
+			}
+		}
+	})
+	return out
+}
+
+// Batch groups values into slices of the given size.
+// The last batch can be smaller.
+func Batch[T any](p *Pipeline, in <-chan T, size int) <-chan []T {
+	if size < 1 {
+		size = 1
+	}
+	out := make(chan []T)
+	p.run(func() error {
+		defer close(out)
+		batch := make([]T, 0, size)
+		for {
+			v, ok := recv(p.ctx, in)
+			if !ok {
+				break
+			}
+			batch = append(batch, v)
+			if len(batch) == size {
+				if !send(p.ctx, out, batch) {
+					return nil
+				}
+				batch = make([]T, 0, size)
+			}
+		}
+		// Don't emit the last batch if the pipeline was cancelled
+		if len(batch) > 0 && p.ctx.Err() == nil {
+			send(p.ctx, out, batch)
+		}
+		return nil
+	})
+	return out
+}
+
+// FanOut applies f to the values of in with the given number of concurrent workers.
+// If ordered is true, the results are emitted in the order of the input values,
+// otherwise in the order in which they're finished.
+func FanOut[T, U any](p *Pipeline, in <-chan T, workers int, ordered bool, f func(context.Context, T) (U, error)) <-chan U {
+	if workers < 1 {
+		workers = 1
+	}
+	if !ordered {
+		outs := make([]<-chan U, workers)
+		for i := range outs {
+			outs[i] = Map(p, in, f)
+		}
+		return Merge(p, outs...)
+	}
+
+	// Every value gets its own result channel, and the result channels are queued in input order.
+	// The queue is buffered so that "workers" values can be processed at the same time.
+	type job struct {
+		v      T
+		result chan U
+	}
+	jobs := make(chan job)
+	queue := make(chan chan U, workers)
+	p.run(func() error {
+		defer close(jobs)
+		defer close(queue)
+		for {
+			v, ok := recv(p.ctx, in)
+			if !ok {
+				return nil
+			}
+			result := make(chan U, 1)
+			if !send(p.ctx, queue, result) || !send(p.ctx, jobs, job{v, result}) {
+				return nil
+			}
+		}
+	})
+	for i := 0; i < workers; i++ {
+		p.run(func() error {
+			for j := range jobs {
+				u, err := f(p.ctx, j.v)
+				if err != nil {
+					return err
+				}
+				// The result waits in its channel until the output loop reaches it in the queue, while this worker takes the next value
+				j.result <- u
+			}
+			return nil
+		})
+	}
+	out := make(chan U)
+	p.run(func() error {
+		defer close(out)
+		for result := range queue {
+			select {
+			case u := <-result:
+				if !send(p.ctx, out, u) {
+					return nil
+				}
+			case <-p.ctx.Done():
+				return nil
+			}
+		}
+		return nil
+	})
+	return out
+}
+
+// Merge combines the values of multiple channels into one (fan-in).
+// The order of the values is not preserved.
+func Merge[T any](p *Pipeline, ins ...<-chan T) <-chan T {
+	out := make(chan T)
+	var wg sync.WaitGroup
+	wg.Add(len(ins))
+	for _, in := range ins {
+		in := in
+		p.run(func() error {
+			defer wg.Done()
+			for {
+				v, ok := recv(p.ctx, in)
+				if !ok || !send(p.ctx, out, v) {
+					return nil
+				}
+			}
+		})
+	}
+	p.run(func() error {
+		wg.Wait()
+		close(out)
+		return nil
+	})
+	return out
+}
+
+// ========
+
+// Reduce combines all values of in with f, starting with initial.
+// It blocks until all stages of the pipeline returned, like Wait.
+func Reduce[T, A any](p *Pipeline, in <-chan T, initial A, f func(A, T) A) (A, error) {
+	acc := initial
+	for v := range in {
+		acc = f(acc, v)
+	}
+	return acc, p.Wait()
+}
+
+// Sink calls f for every value of in.
+// It blocks until all stages of the pipeline returned, like Wait.
+func Sink[T any](p *Pipeline, in <-chan T, f func(context.Context, T) error) error {
+	for v := range in {
+		if err := f(p.ctx, v); err != nil {
+			p.fail(err)
+			break
+		}
+	}
+	return p.Wait()
+}
@@ -0,0 +1,194 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/philippgille/hello-go/leakcheck"
+)
+
+func naturals(p *Pipeline) <-chan int {
+	return Generate(p, func(ctx context.Context, emit func(int) bool) error {
+		for i := 0; ; i++ {
+			if !emit(i) {
+				return nil
+			}
+		}
+	})
+}
+
+func square(ctx context.Context, v int) (int, error) {
+	return v * v, nil
+}
+
+func TestCancel(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	p := New(context.Background())
+	out := Batch(p, Filter(p, Map(p, naturals(p), square), func(v int) bool {
+		return v%2 == 0
+	}), 2)
+	got := [][]int{<-out, <-out}
+	p.Cancel()
+	if err := p.Wait(); err != nil {
+		t.Errorf("Wait() = %v, want nil after Cancel", err)
+	}
+	want := [][]int{{0, 4}, {16, 36}}
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// The stages must also stop when their input channel is neither closed nor sends anything
+func TestCancelBlockedReceive(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	in := make(chan int)
+	p := New(context.Background())
+	Map(p, in, square)
+	Filter(p, in, func(int) bool { return true })
+	Batch(p, in, 2)
+	Merge(p, in, in)
+	FanOut(p, in, 2, true, square)
+	FanOut(p, in, 2, false, square)
+	p.Cancel()
+	if err := p.Wait(); err != nil {
+		t.Errorf("Wait() = %v, want nil after Cancel", err)
+	}
+}
+
+func TestParentContext(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := New(ctx)
+	out := Map(p, naturals(p), square)
+	<-out
+	cancel()
+	if err := Sink(p, out, func(context.Context, int) error { return nil }); !errors.Is(err, context.Canceled) {
+		t.Errorf("Sink() = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestFirstError(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	errFirst := errors.New("first")
+	p := New(context.Background())
+	out := Map(p, naturals(p), func(ctx context.Context, v int) (int, error) {
+		if v == 3 {
+			return 0, errFirst
+		}
+		return v, nil
+	})
+	// Fails with the context's error after the first error, which must not replace it
+	out = Map(p, out, func(ctx context.Context, v int) (int, error) {
+		if v == 2 {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}
+		return v, nil
+	})
+	sum, err := Reduce(p, out, 0, func(acc, v int) int {
+		return acc + v
+	})
+	if !errors.Is(err, errFirst) {
+		t.Errorf("Reduce() error = %v, want %v", err, errFirst)
+	}
+	if sum != 0+1 {
+		t.Errorf("Reduce() = %v, want 1", sum)
+	}
+}
+
+func TestSinkError(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	errSink := errors.New("sink")
+	p := New(context.Background())
+	var got []int
+	err := Sink(p, naturals(p), func(ctx context.Context, v int) error {
+		if v == 2 {
+			return errSink
+		}
+		got = append(got, v)
+		return nil
+	})
+	if !errors.Is(err, errSink) {
+		t.Errorf("Sink() = %v, want %v", err, errSink)
+	}
+	if !slices.Equal(got, []int{0, 1}) {
+		t.Errorf("got %v, want [0 1]", got)
+	}
+}
+
+// slow takes longer for smaller values, so they're finished in reverse order
+func slow(ctx context.Context, v int) (int, error) {
+	select {
+	case <-time.After(time.Duration(10-v) * time.Millisecond):
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+	return v * v, nil
+}
+
+func TestFanOutOrdered(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	p := New(context.Background())
+	var got []int
+	err := Sink(p, FanOut(p, Source(p, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9), 4, true, slow), func(ctx context.Context, v int) error {
+		got = append(got, v)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int{0, 1, 4, 9, 16, 25, 36, 49, 64, 81}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestFanOutUnordered(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	p := New(context.Background())
+	var got []int
+	err := Sink(p, FanOut(p, Source(p, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9), 4, false, slow), func(ctx context.Context, v int) error {
+		got = append(got, v)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Larger values are faster, so the order almost certainly differs, but all results must be there
+	slices.Sort(got)
+	want := []int{0, 1, 4, 9, 16, 25, 36, 49, 64, 81}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestFanOutError(t *testing.T) {
+	for _, ordered := range []bool{true, false} {
+		t.Run(fmt.Sprint("ordered=", ordered), func(t *testing.T) {
+			defer leakcheck.Check(t)()
+
+			errWorker := errors.New("worker")
+			p := New(context.Background())
+			out := FanOut(p, naturals(p), 3, ordered, func(ctx context.Context, v int) (int, error) {
+				if v == 5 {
+					return 0, errWorker
+				}
+				return v, nil
+			})
+			if _, err := Reduce(p, out, 0, func(acc, v int) int { return acc + v }); !errors.Is(err, errWorker) {
+				t.Errorf("Reduce() error = %v, want %v", err, errWorker)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+// Package pubsub is an in-process publish/subscribe broker, which delivers messages to subscribers through channels.
+//
+// Topics consist of segments separated by dots, like "news.sports.football".
+// Subscriptions can use wildcards: "*" matches exactly one segment ("news.*.football"),
+// ">" at the end matches one or more segments ("news.>").
+package pubsub
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ErrClosed is returned when publishing or subscribing on a closed Broker.
+var ErrClosed = errors.New("pubsub: broker is closed")
+
+// Policy determines what happens when a subscriber's buffer is full.
+type Policy int
+
+const (
+	// Block waits until the subscriber reads, so a slow subscriber slows down the publisher.
+	Block Policy = iota
+	// DropOldest removes the oldest message from the buffer to make room for the new one.
+	DropOldest
+	// DropNewest drops the new message.
+	DropNewest
+	// Disconnect unsubscribes the subscriber, which closes its channel.
+	Disconnect
+)
+
+// Message is what subscribers receive.
+type Message[T any] struct {
+	Topic   string
+	Payload T
+}
+
+// Broker delivers published messages to all subscribers with a matching topic.
+type Broker[T any] struct {
+	size   int
+	policy Policy
+
+	mux    sync.RWMutex
+	subs   map[<-chan Message[T]]*subscriber[T]
+	closed bool
+}
+
+type subscriber[T any] struct {
+	pattern []string
+	policy  Policy
+	c       chan Message[T]
+	done    chan struct{} // Closed first when unsubscribing, to unblock publishers
+
+	mux    sync.Mutex // Held while sending, so c isn't closed during a send
+	closed bool
+}
+
+// New creates a Broker whose subscribers get a buffer of the given size and the given slow-consumer policy,
+// unless they use SubscribeWith.
+// It panics if size is negative, like make does for channels.
+func New[T any](size int, policy Policy) *Broker[T] {
+	if size < 0 {
+		panic(fmt.Sprintf("pubsub: negative buffer size %v", size))
+	}
+	return &Broker[T]{
+		size:   size,
+		policy: policy,
+		subs:   make(map[<-chan Message[T]]*subscriber[T]),
+	}
+}
+
+// Subscribe returns a channel that receives all messages whose topic matches the pattern.
+// The channel is closed by Unsubscribe or Close.
+func (b *Broker[T]) Subscribe(pattern string) (<-chan Message[T], error) {
+	return b.SubscribeWith(pattern, b.size, b.policy)
+}
+
+// SubscribeWith is like Subscribe, but with its own buffer size and slow-consumer policy.
+func (b *Broker[T]) SubscribeWith(pattern string, size int, policy Policy) (<-chan Message[T], error) {
+	if size < 0 {
+		return nil, fmt.Errorf("pubsub: negative buffer size %v", size)
+	}
+	segments, err := split(pattern, true)
+	if err != nil {
+		return nil, err
+	}
+	s := &subscriber[T]{
+		pattern: segments,
+		policy:  policy,
+		c:       make(chan Message[T], size),
+		done:    make(chan struct{}),
+	}
+
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	if b.closed {
+		return nil, ErrClosed
+	}
+	b.subs[s.c] = s
+	return s.c, nil
+}
+
+// Unsubscribe stops the delivery to c and closes it.
+// It returns false if c isn't subscribed (anymore).
+func (b *Broker[T]) Unsubscribe(c <-chan Message[T]) bool {
+	b.mux.Lock()
+	s, ok := b.subs[c]
+	delete(b.subs, c)
+	b.mux.Unlock()
+	if ok {
+		s.close()
+	}
+	return ok
+}
+
+// Publish sends the payload to all subscribers whose pattern matches the topic.
+// The topic must not contain wildcards.
+func (b *Broker[T]) Publish(topic string, payload T) error {
+	segments, err := split(topic, false)
+	if err != nil {
+		return err
+	}
+
+	// Collect the subscribers first, so the lock isn't held while blocking on a slow subscriber
+	b.mux.RLock()
+	if b.closed {
+		b.mux.RUnlock()
+		return ErrClosed
+	}
+	var matching []*subscriber[T]
+	for _, s := range b.subs {
+		if match(s.pattern, segments) {
+			matching = append(matching, s)
+		}
+	}
+	b.mux.RUnlock()
+
+	m := Message[T]{topic, payload}
+	for _, s := range matching {
+		if !s.send(m) {
+			b.Unsubscribe(s.c)
+		}
+	}
+	return nil
+}
+
+// Close unsubscribes all subscribers.
+// Publish and Subscribe return ErrClosed afterwards.
+func (b *Broker[T]) Close() {
+	b.mux.Lock()
+	subs := b.subs
+	b.subs = make(map[<-chan Message[T]]*subscriber[T])
+	b.closed = true
+	b.mux.Unlock()
+	for _, s := range subs {
+		s.close()
+	}
+}
+
+// ========
+
+// send delivers m according to the policy.
+// It returns false if the subscriber must be disconnected.
+func (s *subscriber[T]) send(m Message[T]) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.closed {
+		return true
+	}
+	switch s.policy {
+	case DropOldest:
+		for {
+			select {
+			case s.c <- m:
+				return true
+			default:
+			}
+			// Buffer is full (or there's no buffer), remove the oldest message (unless the subscriber just read it)
+			select {
+			case <-s.c:
+			default:
+				if cap(s.c) == 0 {
+					return true
+				}
+			}
+		}
+	case DropNewest:
+		select {
+		case s.c <- m:
+		default:
+		}
+		return true
+	case Disconnect:
+		select {
+		case s.c <- m:
+			return true
+		default:
+			return false
+		}
+	default: // Block
+		select {
+		case s.c <- m:
+		case <-s.done:
+		}
+		return true
+	}
+}
+
+func (s *subscriber[T]) close() {
+	// With the Block policy, send waits for the subscriber to read while it holds s.mux.
+	// An unsubscribed subscriber won't read anymore, so send has to see "done" before s.mux can be taken here.
+	close(s.done)
+	s.mux.Lock()
+	s.closed = true
+	close(s.c)
+	s.mux.Unlock()
+}
+
+// ========
+
+// split splits a topic or pattern into its segments and validates them
+func split(topic string, wildcards bool) ([]string, error) {
+	segments := strings.Split(topic, ".")
+	for i, segment := range segments {
+		switch {
+		case segment == "":
+			return nil, fmt.Errorf("pubsub: empty segment in %q", topic)
+		case segment == "*" || segment == ">":
+			if !wildcards {
+				return nil, fmt.Errorf("pubsub: wildcard in topic %q", topic)
+			}
+			if segment == ">" && i != len(segments)-1 {
+				return nil, fmt.Errorf("pubsub: \">\" must be the last segment in %q", topic)
+			}
+		}
+	}
+	return segments, nil
+}
+
+// match reports whether the topic segments match the pattern segments
+func match(pattern, topic []string) bool {
+	for i, p := range pattern {
+		if p == ">" {
+			return len(topic) > i
+		}
+		if i >= len(topic) || (p != "*" && p != topic[i]) {
+			return false
+		}
+	}
+	return len(pattern) == len(topic)
+}
@@ -0,0 +1,260 @@
+package pubsub
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/philippgille/hello-go/leakcheck"
+)
+
+// payloads receives all messages until the channel is closed
+func payloads(c <-chan Message[int]) []int {
+	var result []int
+	for m := range c {
+		result = append(result, m.Payload)
+	}
+	return result
+}
+
+func TestDropOldest(t *testing.T) {
+	b := New[int](2, DropOldest)
+	c, _ := b.Subscribe("a")
+	for i := 1; i <= 5; i++ {
+		b.Publish("a", i)
+	}
+	b.Close()
+	if got := payloads(c); !slices.Equal(got, []int{4, 5}) {
+		t.Errorf("got %v, want [4 5]", got)
+	}
+}
+
+func TestDropNewest(t *testing.T) {
+	b := New[int](2, DropNewest)
+	c, _ := b.Subscribe("a")
+	for i := 1; i <= 5; i++ {
+		b.Publish("a", i)
+	}
+	b.Close()
+	if got := payloads(c); !slices.Equal(got, []int{1, 2}) {
+		t.Errorf("got %v, want [1 2]", got)
+	}
+}
+
+// Unbuffered subscribers only get messages while they're waiting
+func TestDropUnbuffered(t *testing.T) {
+	for _, policy := range []Policy{DropOldest, DropNewest} {
+		b := New[int](0, policy)
+		c, _ := b.Subscribe("a")
+		if err := b.Publish("a", 1); err != nil {
+			t.Error(err)
+		}
+		b.Close()
+		if got := payloads(c); len(got) != 0 {
+			t.Errorf("policy %v: got %v, want nothing", policy, got)
+		}
+	}
+}
+
+func TestDisconnect(t *testing.T) {
+	b := New[int](1, Disconnect)
+	slow, _ := b.Subscribe("a")
+	fast, _ := b.SubscribeWith("a", 10, Block)
+	for i := 1; i <= 3; i++ {
+		b.Publish("a", i)
+	}
+	// The slow subscriber got the first message and was disconnected with the second one
+	if got := payloads(slow); !slices.Equal(got, []int{1}) {
+		t.Errorf("slow got %v, want [1]", got)
+	}
+	if b.Unsubscribe(slow) {
+		t.Error("Unsubscribe() of a disconnected subscriber = true, want false")
+	}
+	b.Close()
+	if got := payloads(fast); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Errorf("fast got %v, want [1 2 3]", got)
+	}
+}
+
+func TestBlock(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	b := New[int](1, Block)
+	c, _ := b.Subscribe("a")
+	b.Publish("a", 1)
+	published := make(chan error)
+	go func() {
+		published <- b.Publish("a", 2)
+	}()
+	select {
+	case <-published:
+		t.Fatal("Publish didn't block with a full buffer")
+	case <-time.After(10 * time.Millisecond):
+	}
+	if m := <-c; m.Payload != 1 {
+		t.Errorf("got %v, want 1", m.Payload)
+	}
+	if err := <-published; err != nil {
+		t.Error(err)
+	}
+	if m := <-c; m.Payload != 2 {
+		t.Errorf("got %v, want 2", m.Payload)
+	}
+	b.Close()
+}
+
+// A publisher that's blocked on a full subscriber must return when the subscriber goes away
+func TestBlockUnsubscribeClose(t *testing.T) {
+	for _, name := range []string{"Unsubscribe", "Close"} {
+		t.Run(name, func(t *testing.T) {
+			defer leakcheck.Check(t)()
+
+			b := New[int](1, Block)
+			c, _ := b.Subscribe("a")
+			b.Publish("a", 1)
+			published := make(chan error)
+			go func() {
+				published <- b.Publish("a", 2)
+			}()
+			time.Sleep(10 * time.Millisecond)
+			if name == "Unsubscribe" {
+				if !b.Unsubscribe(c) {
+					t.Error("Unsubscribe() = false, want true")
+				}
+			} else {
+				b.Close()
+			}
+			select {
+			case err := <-published:
+				if err != nil {
+					t.Error(err)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("Publish is still blocked")
+			}
+			// The buffered message is still there
+			if got := payloads(c); !slices.Equal(got, []int{1}) {
+				t.Errorf("got %v, want [1]", got)
+			}
+			b.Close()
+		})
+	}
+}
+
+func TestWildcards(t *testing.T) {
+	tests := []struct {
+		pattern string
+		matches []string
+		misses  []string
+	}{
+		{"golang.pkg.fmt", []string{"golang.pkg.fmt"}, []string{"golang.pkg", "golang.pkg.fmt.x", "golang.pkg.os"}},
+		{"golang.*", []string{"golang.pkg", "golang.cmd"}, []string{"golang", "golang.pkg.fmt", "rust.pkg"}},
+		{"golang.*.fmt", []string{"golang.pkg.fmt", "golang.x.fmt"}, []string{"golang.fmt", "golang.pkg.os", "golang.pkg.fmt.x"}},
+		{"*", []string{"golang", "rust"}, []string{"golang.pkg"}},
+		{"golang.>", []string{"golang.pkg", "golang.pkg.fmt"}, []string{"golang", "rust.pkg"}},
+		{">", []string{"golang", "golang.pkg.fmt"}, nil},
+		{"*.pkg.>", []string{"golang.pkg.fmt", "rust.pkg.std.io"}, []string{"golang.pkg", "golang.cmd.go"}},
+	}
+	for _, test := range tests {
+		b := New[int](10, Block)
+		c, err := b.Subscribe(test.pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, topic := range append(test.matches, test.misses...) {
+			b.Publish(topic, 0)
+		}
+		b.Close()
+		var got []string
+		for m := range c {
+			got = append(got, m.Topic)
+		}
+		if !slices.Equal(got, test.matches) {
+			t.Errorf("pattern %q got %v, want %v", test.pattern, got, test.matches)
+		}
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	b := New[int](1, Block)
+	for _, pattern := range []string{"", "a..b", ".a", "a.", "a.>.b", "golang.>.>"} {
+		if _, err := b.Subscribe(pattern); err == nil {
+			t.Errorf("Subscribe(%q) = nil error, want error", pattern)
+		}
+	}
+	for _, topic := range []string{"", "a..b", "a.*", "a.>"} {
+		if err := b.Publish(topic, 0); err == nil {
+			t.Errorf("Publish(%q) = nil, want error", topic)
+		}
+	}
+	if _, err := b.SubscribeWith("a", -1, Block); err == nil {
+		t.Error("SubscribeWith() with negative size = nil error, want error")
+	}
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), "negative") {
+			t.Errorf("New() with negative size panicked with %v", r)
+		}
+	}()
+	New[int](-1, Block)
+}
+
+func TestClosed(t *testing.T) {
+	b := New[int](1, Block)
+	c, _ := b.Subscribe("a")
+	b.Close()
+	b.Close()
+	if _, ok := <-c; ok {
+		t.Error("channel isn't closed")
+	}
+	if b.Unsubscribe(c) {
+		t.Error("Unsubscribe() after Close = true, want false")
+	}
+	if err := b.Publish("a", 1); !errors.Is(err, ErrClosed) {
+		t.Errorf("Publish() = %v, want %v", err, ErrClosed)
+	}
+	if _, err := b.Subscribe("a"); !errors.Is(err, ErrClosed) {
+		t.Errorf("Subscribe() error = %v, want %v", err, ErrClosed)
+	}
+}
+
+// Run with -race
+func TestConcurrent(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	for _, policy := range []Policy{Block, DropOldest, DropNewest, Disconnect} {
+		b := New[int](4, policy)
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					b.Publish(fmt.Sprintf("topic.%v", j%3), j)
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 20; j++ {
+					c, err := b.Subscribe("topic.*")
+					if err != nil {
+						return
+					}
+					// Read a few messages, then unsubscribe while publishers may be blocked
+					for k := 0; k < 3; k++ {
+						select {
+						case <-c:
+						case <-time.After(time.Millisecond):
+						}
+					}
+					b.Unsubscribe(c)
+				}
+			}()
+		}
+		wg.Wait()
+		b.Close()
+	}
+}
@@ -0,0 +1,291 @@
+// Package ratelimit limits how often something happens, instead of pacing it with time.Sleep
+// (like "say" and "fakeFetcher.Fetch" in the concurrency chapter do).
+//
+// A token bucket allows bursts: Up to "burst" events can happen at once, then one per interval.
+// A leaky bucket smoothes events: They happen at most once per interval, and up to "capacity" events can wait for their turn.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/philippgille/hello-go/clock"
+)
+
+// ErrExceeded is returned by Wait when an event can't happen in time, either because the bucket is full
+// or because the context deadline is earlier than the event's turn.
+var ErrExceeded = errors.New("ratelimit: limit exceeded")
+
+// Limiter is implemented by TokenBucket and LeakyBucket.
+type Limiter interface {
+	// Allow reports whether an event can happen now, and takes its turn if it can.
+	Allow() bool
+	// Reserve takes the next turn for an event, no matter how long the caller has to wait for it.
+	Reserve() *Reservation
+	// Wait blocks until an event can happen or ctx is done.
+	// If ctx has a deadline before the event's turn, by the limiter's clock, it returns ErrExceeded right away.
+	Wait(ctx context.Context) error
+}
+
+// Reservation is a turn for an event, returned by Reserve.
+type Reservation struct {
+	ok     bool
+	at     time.Time
+	clock  clock.Clock
+	cancel func() // Gives the turn back, can be nil
+}
+
+// OK reports whether the reservation was successful.
+// If it wasn't, the event must not happen.
+func (r *Reservation) OK() bool {
+	return r.ok
+}
+
+// Delay returns how long the caller has to wait until the event can happen.
+func (r *Reservation) Delay() time.Duration {
+	if d := r.at.Sub(r.clock.Now()); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// Cancel gives the turn back, if it's still in the future.
+// Call it when the event doesn't happen after all.
+func (r *Reservation) Cancel() {
+	if r.ok && r.cancel != nil && r.Delay() > 0 {
+		r.cancel()
+		r.cancel = nil
+	}
+}
+
+// wait implements Limiter.Wait for both buckets
+func wait(ctx context.Context, c clock.Clock, l Limiter) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r := l.Reserve()
+	if !r.OK() {
+		return ErrExceeded
+	}
+	d := r.Delay()
+	if d == 0 {
+		return nil
+	}
+	// The deadline is compared with the limiter's clock, like the turn, so a clock.Fake decides about both.
+	// Only ctx.Done fires in real time.
+	if deadline, ok := ctx.Deadline(); ok && deadline.Sub(c.Now()) < d {
+		r.Cancel()
+		return ErrExceeded
+	}
+	timer := c.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return ctx.Err()
+	}
+}
+
+// ========
+
+// TokenBucket gets a new token every interval, up to "burst" tokens.
+// Each event takes one token.
+type TokenBucket struct {
+	clock    clock.Clock
+	interval time.Duration
+	burst    int
+
+	mux    sync.Mutex
+	tokens float64 // Can be negative when tokens are reserved in advance
+	last   time.Time
+}
+
+// NewTokenBucket creates a full TokenBucket.
+func NewTokenBucket(c clock.Clock, interval time.Duration, burst int) *TokenBucket {
+	return &TokenBucket{
+		clock:    c,
+		interval: interval,
+		burst:    burst,
+		tokens:   float64(burst),
+		last:     c.Now(),
+	}
+}
+
+// Allow takes a token if there is one.
+func (b *TokenBucket) Allow() bool {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.refill()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Reserve takes a token, which can be one that's only added in the future.
+// It's not OK if the burst is 0, because then there are never any tokens.
+func (b *TokenBucket) Reserve() *Reservation {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	r := &Reservation{clock: b.clock}
+	if b.burst < 1 {
+		return r
+	}
+	now := b.refill()
+	b.tokens--
+	r.ok = true
+	r.at = now
+	if b.tokens < 0 {
+		r.at = now.Add(time.Duration(-b.tokens * float64(b.interval)))
+	}
+	r.cancel = func() {
+		b.mux.Lock()
+		defer b.mux.Unlock()
+		b.refill()
+		b.tokens++
+		if b.tokens > float64(b.burst) {
+			b.tokens = float64(b.burst)
+		}
+	}
+	return r
+}
+
+// Wait blocks until a token is available or ctx is done.
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	return wait(ctx, b.clock, b)
+}
+
+// refill adds the tokens for the time since the last refill. b.mux must be held.
+func (b *TokenBucket) refill() time.Time {
+	now := b.clock.Now()
+	if b.interval <= 0 {
+		b.tokens = float64(b.burst)
+	} else if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += float64(elapsed) / float64(b.interval)
+		if b.tokens > float64(b.burst) {
+			b.tokens = float64(b.burst)
+		}
+	}
+	b.last = now
+	return now
+}
+
+// ========
+
+// LeakyBucket lets one event happen per interval.
+// Up to "capacity" events can wait for their turn, more are rejected.
+type LeakyBucket struct {
+	clock    clock.Clock
+	interval time.Duration
+	capacity int
+
+	mux  sync.Mutex
+	next time.Time // Turn of the next event
+}
+
+// NewLeakyBucket creates an empty LeakyBucket.
+func NewLeakyBucket(c clock.Clock, interval time.Duration, capacity int) *LeakyBucket {
+	return &LeakyBucket{
+		clock:    c,
+		interval: interval,
+		capacity: capacity,
+	}
+}
+
+// Allow reports whether it's the turn of an event right now.
+func (b *LeakyBucket) Allow() bool {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	now := b.clock.Now()
+	if b.next.After(now) {
+		return false
+	}
+	b.next = now.Add(b.interval)
+	return true
+}
+
+// Reserve takes the next turn.
+// It's not OK if "capacity" events are already waiting.
+func (b *LeakyBucket) Reserve() *Reservation {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	r := &Reservation{clock: b.clock}
+	now := b.clock.Now()
+	at := b.next
+	if at.Before(now) {
+		at = now
+	}
+	if b.interval > 0 && int(at.Sub(now)/b.interval) > b.capacity {
+		return r
+	}
+	b.next = at.Add(b.interval)
+	r.ok = true
+	r.at = at
+	r.cancel = func() {
+		b.mux.Lock()
+		defer b.mux.Unlock()
+		// Only the last turn can be given back, otherwise the events after it would have to move forward
+		if b.next.Equal(at.Add(b.interval)) {
+			b.next = at
+		}
+	}
+	return r
+}
+
+// Wait blocks until it's the turn of the event or ctx is done.
+// It returns ErrExceeded if the bucket is full.
+func (b *LeakyBucket) Wait(ctx context.Context) error {
+	return wait(ctx, b.clock, b)
+}
+
+// ========
+
+// Group has one Limiter per key, for example per user or per host.
+type Group[K comparable] struct {
+	newLimiter func() Limiter
+
+	mux      sync.Mutex
+	limiters map[K]Limiter
+}
+
+// NewGroup creates a Group that creates Limiters with newLimiter when a key is used for the first time.
+func NewGroup[K comparable](newLimiter func() Limiter) *Group[K] {
+	return &Group[K]{
+		newLimiter: newLimiter,
+		limiters:   make(map[K]Limiter),
+	}
+}
+
+// Get returns the Limiter of the key.
+func (g *Group[K]) Get(key K) Limiter {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	l, ok := g.limiters[key]
+	if !ok {
+		l = g.newLimiter()
+		g.limiters[key] = l
+	}
+	return l
+}
+
+// Allow calls Allow on the Limiter of the key.
+func (g *Group[K]) Allow(key K) bool {
+	return g.Get(key).Allow()
+}
+
+// Wait calls Wait on the Limiter of the key.
+func (g *Group[K]) Wait(ctx context.Context, key K) error {
+	return g.Get(key).Wait(ctx)
+}
+
+// Remove forgets the Limiter of the key.
+func (g *Group[K]) Remove(key K) {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	delete(g.limiters, key)
+}
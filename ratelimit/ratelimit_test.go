@@ -0,0 +1,279 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/philippgille/hello-go/clock"
+	"github.com/philippgille/hello-go/leakcheck"
+)
+
+var start = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// allowed returns how many of n calls of Allow return true
+func allowed(l Limiter, n int) int {
+	result := 0
+	for i := 0; i < n; i++ {
+		if l.Allow() {
+			result++
+		}
+	}
+	return result
+}
+
+func TestTokenBucketBurst(t *testing.T) {
+	c := clock.NewFake(start)
+	b := NewTokenBucket(c, time.Second, 3)
+	if got := allowed(b, 5); got != 3 {
+		t.Errorf("allowed %v of a full bucket, want 3", got)
+	}
+	c.Advance(time.Second)
+	if got := allowed(b, 5); got != 1 {
+		t.Errorf("allowed %v after 1 interval, want 1", got)
+	}
+	// Tokens are refilled proportionally
+	c.Advance(500 * time.Millisecond)
+	if b.Allow() {
+		t.Error("allowed after half an interval")
+	}
+	c.Advance(500 * time.Millisecond)
+	if !b.Allow() {
+		t.Error("not allowed after two halves of an interval")
+	}
+	// But only up to the burst
+	c.Advance(time.Minute)
+	if got := allowed(b, 5); got != 3 {
+		t.Errorf("allowed %v after a long time, want 3", got)
+	}
+}
+
+func TestTokenBucketReserve(t *testing.T) {
+	c := clock.NewFake(start)
+	b := NewTokenBucket(c, time.Second, 1)
+	var reservations []*Reservation
+	for i, want := range []time.Duration{0, time.Second, 2 * time.Second} {
+		r := b.Reserve()
+		if !r.OK() || r.Delay() != want {
+			t.Errorf("reservation %v: OK() = %v, Delay() = %v, want true, %v", i, r.OK(), r.Delay(), want)
+		}
+		reservations = append(reservations, r)
+	}
+	// Cancelling the last one gives its token back, so the next reservation gets the same delay
+	reservations[2].Cancel()
+	if d := b.Reserve().Delay(); d != 2*time.Second {
+		t.Errorf("Delay() after Cancel = %v, want 2s", d)
+	}
+	// Cancelling twice doesn't give back two tokens
+	reservations[1].Cancel()
+	reservations[1].Cancel()
+	if d := b.Reserve().Delay(); d != 2*time.Second {
+		t.Errorf("Delay() after cancelling twice = %v, want 2s", d)
+	}
+	// The delay shrinks as time passes
+	c.Advance(1500 * time.Millisecond)
+	if d := reservations[1].Delay(); d != 0 {
+		t.Errorf("Delay() = %v, want 0 after the turn", d)
+	}
+}
+
+// Reservations that are already due can't be given back, because the event may have happened already
+func TestTokenBucketCancelDue(t *testing.T) {
+	c := clock.NewFake(start)
+	b := NewTokenBucket(c, time.Second, 1)
+	b.Reserve().Cancel()
+	if b.Allow() {
+		t.Error("Cancel() gave back a token whose turn was now")
+	}
+}
+
+func TestTokenBucketZeroBurst(t *testing.T) {
+	b := NewTokenBucket(clock.NewFake(start), time.Second, 0)
+	if b.Allow() {
+		t.Error("Allow() = true, want false")
+	}
+	if b.Reserve().OK() {
+		t.Error("Reserve().OK() = true, want false")
+	}
+	if err := b.Wait(context.Background()); !errors.Is(err, ErrExceeded) {
+		t.Errorf("Wait() = %v, want %v", err, ErrExceeded)
+	}
+}
+
+func TestWait(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	c := clock.NewFake(start)
+	b := NewTokenBucket(c, time.Second, 1)
+	if err := b.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() {
+		done <- b.Wait(context.Background())
+	}()
+	c.BlockUntil(1)
+	c.Advance(999 * time.Millisecond)
+	select {
+	case <-done:
+		t.Fatal("Wait returned before the next token")
+	case <-time.After(10 * time.Millisecond):
+	}
+	c.Advance(time.Millisecond)
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWaitCancel(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	c := clock.NewFake(start)
+	b := NewTokenBucket(c, time.Second, 1)
+	b.Allow()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- b.Wait(ctx)
+	}()
+	c.BlockUntil(1)
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait() = %v, want %v", err, context.Canceled)
+	}
+	// The token was given back
+	if d := b.Reserve().Delay(); d != time.Second {
+		t.Errorf("Delay() = %v, want 1s", d)
+	}
+	if err := b.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait() with a cancelled context = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestWaitDeadline(t *testing.T) {
+	// Context deadlines are set in real time, so the fake clock starts now
+	now := time.Now()
+	c := clock.NewFake(now)
+	b := NewTokenBucket(c, time.Hour, 1)
+	b.Allow()
+	ctx, cancel := context.WithDeadline(context.Background(), now.Add(time.Minute))
+	defer cancel()
+	if err := b.Wait(ctx); !errors.Is(err, ErrExceeded) {
+		t.Errorf("Wait() = %v, want %v", err, ErrExceeded)
+	}
+	// The turn was given back
+	if d := b.Reserve().Delay(); d != time.Hour {
+		t.Errorf("Delay() = %v, want 1h", d)
+	}
+}
+
+// The deadline is compared with the fake time, not with the real time
+func TestWaitDeadlineFakeClock(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	now := time.Now()
+	ctx, cancel := context.WithDeadline(context.Background(), now.Add(time.Minute))
+	defer cancel()
+
+	// The fake clock is behind the real one, so the next token comes before the deadline in fake time, but not in real time
+	c := clock.NewFake(now.Add(-time.Minute))
+	b := NewTokenBucket(c, 90*time.Second, 1)
+	b.Allow()
+	done := make(chan error)
+	go func() {
+		done <- b.Wait(ctx)
+	}()
+	c.BlockUntil(1)
+	c.Advance(90 * time.Second)
+	if err := <-done; err != nil {
+		t.Errorf("Wait() with the deadline after the next token in fake time = %v, want nil", err)
+	}
+
+	// And the other way around
+	c = clock.NewFake(now.Add(time.Minute))
+	b = NewTokenBucket(c, 30*time.Second, 1)
+	b.Allow()
+	if err := b.Wait(ctx); !errors.Is(err, ErrExceeded) {
+		t.Errorf("Wait() with the deadline before the next token in fake time = %v, want %v", err, ErrExceeded)
+	}
+}
+
+func TestLeakyBucketAllow(t *testing.T) {
+	c := clock.NewFake(start)
+	b := NewLeakyBucket(c, time.Second, 5)
+	if got := allowed(b, 3); got != 1 {
+		t.Errorf("allowed %v, want 1", got)
+	}
+	c.Advance(time.Second)
+	if got := allowed(b, 3); got != 1 {
+		t.Errorf("allowed %v after 1 interval, want 1", got)
+	}
+	// No bursts after a long time
+	c.Advance(time.Minute)
+	if got := allowed(b, 3); got != 1 {
+		t.Errorf("allowed %v after a long time, want 1", got)
+	}
+}
+
+func TestLeakyBucketCapacity(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	c := clock.NewFake(start)
+	b := NewLeakyBucket(c, time.Second, 2)
+	// One event now and two waiting ones
+	var last *Reservation
+	for i, want := range []time.Duration{0, time.Second, 2 * time.Second} {
+		last = b.Reserve()
+		if !last.OK() || last.Delay() != want {
+			t.Errorf("reservation %v: OK() = %v, Delay() = %v, want true, %v", i, last.OK(), last.Delay(), want)
+		}
+	}
+	if b.Reserve().OK() {
+		t.Error("Reserve() of a full bucket is OK")
+	}
+	if b.Allow() {
+		t.Error("Allow() of a full bucket = true")
+	}
+	if err := b.Wait(context.Background()); !errors.Is(err, ErrExceeded) {
+		t.Errorf("Wait() of a full bucket = %v, want %v", err, ErrExceeded)
+	}
+	// Cancelling the last turn makes room for one more
+	last.Cancel()
+	if r := b.Reserve(); !r.OK() || r.Delay() != 2*time.Second {
+		t.Errorf("Reserve() after Cancel: OK() = %v, Delay() = %v, want true, 2s", r.OK(), r.Delay())
+	}
+	// Time makes room as well
+	c.Advance(time.Second)
+	if r := b.Reserve(); !r.OK() || r.Delay() != 2*time.Second {
+		t.Errorf("Reserve() after 1s: OK() = %v, Delay() = %v, want true, 2s", r.OK(), r.Delay())
+	}
+}
+
+func TestGroup(t *testing.T) {
+	c := clock.NewFake(start)
+	g := NewGroup[string](func() Limiter {
+		return NewTokenBucket(c, time.Second, 2)
+	})
+	if got := allowed(g.Get("alice"), 3); got != 2 {
+		t.Errorf("alice allowed %v, want 2", got)
+	}
+	// Each key has its own limiter
+	if !g.Allow("bob") {
+		t.Error("bob isn't allowed")
+	}
+	if g.Allow("alice") {
+		t.Error("alice is allowed again")
+	}
+	if g.Get("alice") != g.Get("alice") {
+		t.Error("Get() returns different limiters for the same key")
+	}
+	// Removing a key starts over with a full bucket
+	g.Remove("alice")
+	if err := g.Wait(context.Background(), "alice"); err != nil {
+		t.Error(err)
+	}
+	if !g.Allow("alice") {
+		t.Error("alice isn't allowed after Remove")
+	}
+}
@@ -0,0 +1,229 @@
+// Package readers provides io.Reader implementations that can be combined, like "aReader" in the methods chapter,
+// which is Repeat([]byte("A")).
+// They're useful for generating test data and for testing code that consumes readers.
+package readers
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"time"
+
+	"github.com/philippgille/hello-go/clock"
+)
+
+// ErrInjected is returned by ErrorAfter when no error is given.
+var ErrInjected = errors.New("readers: injected error")
+
+// ErrOffset is returned by readers of Section with a negative offset.
+var ErrOffset = errors.New("readers: negative offset")
+
+// ========
+
+type repeat struct {
+	pattern []byte
+	pos     int // Position in the pattern of the next byte
+}
+
+// Repeat returns an endless reader of the pattern, like "abcabcabc..." for "abc".
+// It returns io.EOF right away if the pattern is empty.
+func Repeat(pattern []byte) io.Reader {
+	return &repeat{pattern: append([]byte(nil), pattern...)}
+}
+
+func (r *repeat) Read(b []byte) (int, error) {
+	if len(r.pattern) == 0 {
+		return 0, io.EOF
+	}
+	for i := range b {
+		b[i] = r.pattern[r.pos]
+		r.pos = (r.pos + 1) % len(r.pattern)
+	}
+	return len(b), nil
+}
+
+// ========
+
+// Limit returns a reader that reads at most n bytes from r, like io.LimitReader.
+func Limit(r io.Reader, n int64) io.Reader {
+	return io.LimitReader(r, n)
+}
+
+type section struct {
+	r    io.Reader
+	skip int64 // Bytes that still have to be skipped
+	n    int64 // Bytes that are left after skipping
+}
+
+// Section returns a reader of the n bytes of r that start offset bytes after the current position of r.
+// It reads from r, so afterwards r is at the end of the section, or where reading stopped.
+// The offset is skipped with Seek if r is an io.Seeker (like *os.File), otherwise the bytes are read and discarded.
+// Reading returns ErrOffset if offset is negative.
+func Section(r io.Reader, offset, n int64) io.Reader {
+	return &section{r, offset, n}
+}
+
+func (s *section) Read(b []byte) (int, error) {
+	if s.skip < 0 {
+		return 0, ErrOffset
+	}
+	if s.skip > 0 {
+		if err := s.skipOffset(); err != nil {
+			return 0, err
+		}
+	}
+	if s.n <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(b)) > s.n {
+		b = b[:s.n]
+	}
+	n, err := s.r.Read(b)
+	s.n -= int64(n)
+	return n, err
+}
+
+func (s *section) skipOffset() error {
+	// Seeking fails for pipes, which are *os.File as well, so then the bytes are discarded, too
+	if seeker, ok := s.r.(io.Seeker); ok {
+		if _, err := seeker.Seek(s.skip, io.SeekCurrent); err == nil {
+			s.skip = 0
+			return nil
+		}
+	}
+	n, err := io.CopyN(io.Discard, s.r, s.skip)
+	s.skip -= n
+	return err
+}
+
+// ========
+
+type throttle struct {
+	r              io.Reader
+	clock          clock.Clock
+	bytesPerSecond int
+	start          time.Time
+	total          int64
+}
+
+// Throttle returns a reader that reads from r with at most bytesPerSecond on average.
+// It reads in chunks of up to a tenth of a second, and sleeps after each chunk until it's time for the next one.
+// It panics if bytesPerSecond isn't positive, like clock.NewTicker with a non-positive interval.
+func Throttle(r io.Reader, c clock.Clock, bytesPerSecond int) io.Reader {
+	if bytesPerSecond <= 0 {
+		panic(fmt.Sprintf("readers: non-positive rate %v for Throttle", bytesPerSecond))
+	}
+	return &throttle{r: r, clock: c, bytesPerSecond: bytesPerSecond}
+}
+
+func (t *throttle) Read(b []byte) (int, error) {
+	if t.start.IsZero() {
+		t.start = t.clock.Now()
+	}
+	if chunk := max(1, t.bytesPerSecond/10); len(b) > chunk {
+		b = b[:chunk]
+	}
+	n, err := t.r.Read(b)
+	t.total += int64(n)
+	// The time when all bytes so far are allowed, based on the start and not on the last read, so rounding errors don't add up
+	due := t.start.Add(time.Duration(float64(t.total) / float64(t.bytesPerSecond) * float64(time.Second)))
+	if d := due.Sub(t.clock.Now()); d > 0 {
+		t.clock.Sleep(d)
+	}
+	return n, err
+}
+
+// ========
+
+type progress struct {
+	r     io.Reader
+	total int64
+	f     func(total int64)
+}
+
+// Progress returns a reader that calls f with the total number of bytes read so far, after each read of r that returned bytes.
+func Progress(r io.Reader, f func(total int64)) io.Reader {
+	return &progress{r: r, f: f}
+}
+
+func (p *progress) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.total += int64(n)
+		p.f(p.total)
+	}
+	return n, err
+}
+
+// ========
+
+// Random returns an endless reader of random bytes.
+// The same seed leads to the same bytes, so tests with random data can be reproduced.
+// The bytes aren't meant for cryptography, use crypto/rand for that.
+func Random(seed int64) io.Reader {
+	var s [32]byte
+	binary.LittleEndian.PutUint64(s[:], uint64(seed))
+	return rand.NewChaCha8(s)
+}
+
+// ========
+
+type errorAfter struct {
+	r    io.Reader
+	left int64
+	err  error
+}
+
+// ErrorAfter returns a reader that reads n bytes from r (or less, if r ends before), and then returns err.
+// If err is nil, it returns ErrInjected.
+func ErrorAfter(r io.Reader, n int64, err error) io.Reader {
+	if err == nil {
+		err = ErrInjected
+	}
+	return &errorAfter{r, n, err}
+}
+
+func (e *errorAfter) Read(b []byte) (int, error) {
+	if e.left <= 0 {
+		return 0, e.err
+	}
+	if int64(len(b)) > e.left {
+		b = b[:e.left]
+	}
+	n, err := e.r.Read(b)
+	e.left -= int64(n)
+	return n, err
+}
+
+type shortReads struct {
+	r     io.Reader
+	after int64
+	max   int
+}
+
+// ShortReadsAfter returns a reader that reads normally from r for the first n bytes,
+// but then returns at most max bytes per read (at least 1).
+// Code that expects full buffers, instead of using io.ReadFull, breaks with it.
+func ShortReadsAfter(r io.Reader, n int64, max int) io.Reader {
+	if max < 1 {
+		max = 1
+	}
+	return &shortReads{r, n, max}
+}
+
+func (s *shortReads) Read(b []byte) (int, error) {
+	switch {
+	case s.after > 0:
+		// Don't read beyond the point where short reads start
+		if int64(len(b)) > s.after {
+			b = b[:s.after]
+		}
+	case len(b) > s.max:
+		b = b[:s.max]
+	}
+	n, err := s.r.Read(b)
+	s.after -= int64(n)
+	return n, err
+}
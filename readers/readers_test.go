@@ -0,0 +1,371 @@
+package readers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/philippgille/hello-go/clock"
+	"github.com/philippgille/hello-go/leakcheck"
+)
+
+func TestRepeat(t *testing.T) {
+	pattern := []byte("abc")
+	r := Repeat(pattern)
+	pattern[0] = 'x' // The pattern is copied
+	var got []byte
+	for _, size := range []int{1, 2, 4, 0, 7} {
+		b := make([]byte, size)
+		n, err := r.Read(b)
+		if n != size || err != nil {
+			t.Fatalf("Read(%v bytes) = %v, %v", size, n, err)
+		}
+		got = append(got, b...)
+	}
+	if want := "abcabcabcabcab"; string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if n, err := Repeat(nil).Read(make([]byte, 3)); n != 0 || err != io.EOF {
+		t.Errorf("Read of empty pattern = %v, %v, want 0, EOF", n, err)
+	}
+}
+
+// readerAt hides the Seek method of *strings.Reader
+type readerAt struct {
+	*strings.Reader
+}
+
+func (r readerAt) Read(b []byte) (int, error) {
+	return r.Reader.Read(b)
+}
+
+func (r readerAt) ReadAt(b []byte, off int64) (int, error) {
+	return r.Reader.ReadAt(b, off)
+}
+
+// countingSeeker counts the bytes that are read, to check that Seek is used for skipping
+type countingSeeker struct {
+	*strings.Reader
+	read int
+}
+
+func (r *countingSeeker) Read(b []byte) (int, error) {
+	n, err := r.Reader.Read(b)
+	r.read += n
+	return n, err
+}
+
+// pipe returns the read end of a pipe that contains s, which is an *os.File that fails to seek
+func pipe(t *testing.T, s string) io.Reader {
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pr.Close() })
+	go func() {
+		pw.WriteString(s)
+		pw.Close()
+	}()
+	return pr
+}
+
+func TestSection(t *testing.T) {
+	const data = "0123456789"
+	// The same input as different types of readers must lead to the same section and the same position afterwards
+	readers := []struct {
+		name string
+		r    func(t *testing.T) io.Reader
+	}{
+		{"Seeker and ReaderAt", func(*testing.T) io.Reader { return strings.NewReader(data) }},
+		{"file", func(t *testing.T) io.Reader {
+			f, err := os.CreateTemp(t.TempDir(), "section")
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { f.Close() })
+			if _, err := f.WriteString(data); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				t.Fatal(err)
+			}
+			return f
+		}},
+		{"only ReaderAt", func(*testing.T) io.Reader { return readerAt{strings.NewReader(data)} }},
+		{"only Reader", func(*testing.T) io.Reader { return iotest.OneByteReader(strings.NewReader(data)) }},
+		{"pipe", func(t *testing.T) io.Reader { return pipe(t, data) }},
+	}
+	sections := []struct {
+		offset, n int64
+		want      string
+	}{
+		{0, 3, "234"},
+		{3, 4, "5678"},
+		{5, 10, "789"},
+		{8, 5, ""},
+		{20, 5, ""},
+		{3, 0, ""},
+		{3, -1, ""},
+	}
+	for _, rt := range readers {
+		for _, s := range sections {
+			t.Run(fmt.Sprintf("%v/%v,%v", rt.name, s.offset, s.n), func(t *testing.T) {
+				r := rt.r(t)
+				// The section is relative to the current position, not to the start
+				if _, err := io.ReadFull(r, make([]byte, 2)); err != nil {
+					t.Fatal(err)
+				}
+				got, err := io.ReadAll(Section(r, s.offset, s.n))
+				if string(got) != s.want || err != nil {
+					t.Fatalf("got %q, %v, want %q", got, err, s.want)
+				}
+				// r continues after the section
+				rest, err := io.ReadAll(r)
+				if want := data[min(len(data), 2+int(s.offset)+len(s.want)):]; string(rest) != want || err != nil {
+					t.Errorf("rest of r = %q, %v, want %q", rest, err, want)
+				}
+			})
+		}
+	}
+
+	// Seekers skip without reading
+	cs := &countingSeeker{Reader: strings.NewReader(data)}
+	if got, _ := io.ReadAll(Section(cs, 6, 2)); string(got) != "67" || cs.read != 2 {
+		t.Errorf("section of Seeker = %q after reading %v bytes, want \"67\" after 2", got, cs.read)
+	}
+
+	// A negative offset is an error, for all types
+	for _, rt := range readers {
+		r := rt.r(t)
+		if n, err := Section(r, -1, 3).Read(make([]byte, 3)); n != 0 || !errors.Is(err, ErrOffset) {
+			t.Errorf("%v: Read with negative offset = %v, %v, want 0, %v", rt.name, n, err, ErrOffset)
+		}
+		if rest, _ := io.ReadAll(r); string(rest) != data {
+			t.Errorf("%v: negative offset consumed r, rest is %q", rt.name, rest)
+		}
+	}
+
+	// Like in the methods chapter
+	got, _ := io.ReadAll(Section(Repeat([]byte("abc")), 1, 7))
+	if want := "bcabcab"; string(got) != want {
+		t.Errorf("section of Repeat = %q, want %q", got, want)
+	}
+	// The error of r is returned while skipping
+	if _, err := io.ReadAll(Section(ErrorAfter(Repeat([]byte("a")), 2, nil), 5, 1)); !errors.Is(err, ErrInjected) {
+		t.Errorf("error while skipping = %v, want ErrInjected", err)
+	}
+	if err := iotest.TestReader(Section(strings.NewReader(data), 2, 5), []byte("23456")); err != nil {
+		t.Error(err)
+	}
+	if err := iotest.TestReader(Section(Repeat([]byte(data)), 12, 5), []byte("23456")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	tests := []struct {
+		bytesPerSecond int
+		size           int
+		chunk          int           // Bytes per read
+		step           time.Duration // Time per chunk
+	}{
+		{100, 1000, 10, 100 * time.Millisecond},
+		{250, 500, 25, 100 * time.Millisecond},
+		{5, 10, 1, 200 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.bytesPerSecond), func(t *testing.T) {
+			defer leakcheck.Check(t)()
+			start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+			c := clock.NewFake(start)
+			var total atomic.Int64
+			r := Progress(Throttle(Limit(Repeat([]byte("x")), int64(tt.size)), c, tt.bytesPerSecond), func(n int64) { total.Store(n) })
+
+			done := make(chan []byte)
+			go func() {
+				b, _ := io.ReadAll(r)
+				done <- b
+			}()
+			// Each chunk is only returned after the time for it has passed
+			for i := 0; i < tt.size/tt.chunk; i++ {
+				c.BlockUntil(1)
+				if got, want := total.Load(), int64(i*tt.chunk); got != want {
+					t.Fatalf("after %v: read %v bytes, want %v", c.Since(start), got, want)
+				}
+				c.Advance(tt.step)
+			}
+			b := <-done
+			if len(b) != tt.size {
+				t.Errorf("read %v bytes, want %v", len(b), tt.size)
+			}
+			if got, want := c.Since(start), time.Duration(tt.size/tt.chunk)*tt.step; got != want {
+				t.Errorf("took %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestThrottleFastReader(t *testing.T) {
+	// When reading is slower than the rate, Throttle doesn't sleep
+	c := clock.NewFake(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC))
+	r := Throttle(Limit(Repeat([]byte("x")), 100), c, 10)
+	b := make([]byte, 1)
+	// The time is counted from the first Read, which doesn't sleep for 0 bytes
+	if _, err := r.Read(b[:0]); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		c.Advance(time.Second)
+		if _, err := r.Read(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestThrottleInvalidRate(t *testing.T) {
+	for _, rate := range []int{0, -1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Throttle with rate %v didn't panic", rate)
+				}
+			}()
+			Throttle(Repeat([]byte("x")), clock.Real, rate)
+		}()
+	}
+}
+
+func TestProgress(t *testing.T) {
+	var totals []int64
+	r := Progress(iotest.HalfReader(strings.NewReader("0123456789")), func(n int64) { totals = append(totals, n) })
+	b, err := io.ReadAll(r)
+	if string(b) != "0123456789" || err != nil {
+		t.Fatalf("got %q, %v", b, err)
+	}
+	// f isn't called for the final read, which returns 0 bytes and EOF
+	if len(totals) == 0 || totals[len(totals)-1] != 10 {
+		t.Errorf("totals = %v, want them to end with 10", totals)
+	}
+	for i := 1; i < len(totals); i++ {
+		if totals[i] <= totals[i-1] {
+			t.Errorf("totals = %v, want them to increase", totals)
+		}
+	}
+}
+
+func TestRandom(t *testing.T) {
+	read := func(seed int64) []byte {
+		b := make([]byte, 1000)
+		if _, err := io.ReadFull(Random(seed), b); err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	if !bytes.Equal(read(1), read(1)) {
+		t.Error("same seed, different bytes")
+	}
+	if bytes.Equal(read(1), read(2)) {
+		t.Error("different seeds, same bytes")
+	}
+	// Roughly uniform: with about 4 of each byte value, most values appear
+	seen := map[byte]bool{}
+	for _, c := range read(3) {
+		seen[c] = true
+	}
+	if len(seen) < 200 {
+		t.Errorf("only %v different byte values in 1000 random bytes", len(seen))
+	}
+}
+
+func TestErrorAfter(t *testing.T) {
+	errCustom := errors.New("custom")
+	tests := []struct {
+		name    string
+		data    string
+		n       int64
+		err     error
+		want    string
+		wantErr error
+	}{
+		{"default error", "hello", 3, nil, "hel", ErrInjected},
+		{"custom error", "hello", 3, errCustom, "hel", errCustom},
+		{"at start", "hello", 0, nil, "", ErrInjected},
+		{"at end", "hello", 5, nil, "hello", ErrInjected},
+		{"after end", "hi", 5, nil, "hi", nil}, // EOF of r comes first, and ReadAll doesn't return it
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := strings.NewReader(tt.data)
+			got, err := io.ReadAll(ErrorAfter(src, tt.n, tt.err))
+			if string(got) != tt.want || err != tt.wantErr {
+				t.Errorf("got %q, %v, want %q, %v", got, err, tt.want, tt.wantErr)
+			}
+			// It doesn't read more than n bytes from r
+			if left := int64(len(tt.data)) - min(tt.n, int64(len(tt.data))); int64(src.Len()) != left {
+				t.Errorf("%v bytes left in r, want %v", src.Len(), left)
+			}
+		})
+	}
+
+	// The error stays
+	r := ErrorAfter(Repeat([]byte("a")), 1, nil)
+	r.Read(make([]byte, 10))
+	for i := 0; i < 3; i++ {
+		if n, err := r.Read(make([]byte, 10)); n != 0 || err != ErrInjected {
+			t.Errorf("Read after error = %v, %v, want 0, ErrInjected", n, err)
+		}
+	}
+}
+
+func TestShortReadsAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		n     int64
+		max   int
+		sizes []int
+	}{
+		{"after some bytes", 8, 3, []int{8, 3, 3, 3, 3}},
+		{"from start", 0, 5, []int{5, 5, 5, 5}},
+		{"uneven", 3, 7, []int{3, 7, 7, 3}},
+		{"max below 1", 18, 0, []int{18, 1, 1}},
+		{"after end", 50, 3, []int{20}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := ShortReadsAfter(Limit(Repeat([]byte("x")), 20), tt.n, tt.max)
+			var sizes []int
+			b := make([]byte, 100)
+			for {
+				n, err := r.Read(b)
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				sizes = append(sizes, n)
+			}
+			if fmt.Sprint(sizes) != fmt.Sprint(tt.sizes) {
+				t.Errorf("read sizes %v, want %v", sizes, tt.sizes)
+			}
+		})
+	}
+
+	const data = "The quick brown fox jumps over the lazy dog"
+	if err := iotest.TestReader(ShortReadsAfter(strings.NewReader(data), 10, 4), []byte(data)); err != nil {
+		t.Error(err)
+	}
+	// io.ReadFull works with short reads
+	b := make([]byte, len(data))
+	if _, err := io.ReadFull(ShortReadsAfter(strings.NewReader(data), 0, 1), b); err != nil || string(b) != data {
+		t.Errorf("ReadFull = %q, %v", b, err)
+	}
+}
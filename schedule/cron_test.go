@@ -0,0 +1,99 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	// A Monday
+	from := time.Date(2024, time.January, 1, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"* * * * *", "2024-01-01 10:08"},
+		{"*/15 * * * *", "2024-01-01 10:15"},
+		{"0 * * * *", "2024-01-01 11:00"},
+		{"@hourly", "2024-01-01 11:00"},
+		{"@daily", "2024-01-02 00:00"},
+		{"@weekly", "2024-01-07 00:00"},
+		{"@monthly", "2024-02-01 00:00"},
+		{"@yearly", "2025-01-01 00:00"},
+		{"30 9 * * *", "2024-01-02 09:30"},
+		{"*/15 9-17 * * mon-fri", "2024-01-01 10:15"},
+		{"0 9 * * sat,sun", "2024-01-06 09:00"},
+		// Sunday is 0 and 7
+		{"0 9 * * 7", "2024-01-07 09:00"},
+		{"0 0 1-10/3 * *", "2024-01-04 00:00"},
+		{"5/20 * * * *", "2024-01-01 10:25"},
+		{"0 0 29 feb *", "2024-02-29 00:00"},
+		{"0 0 1 JAN,Jul *", "2024-07-01 00:00"},
+		// Both day fields are restricted, so either one has to match: the 15th or the next Friday
+		{"0 0 15 * fri", "2024-01-05 00:00"},
+		// Never matches
+		{"0 0 30 2 *", "0001-01-01 00:00"},
+	}
+	for _, test := range tests {
+		c, err := Cron(test.expr)
+		if err != nil {
+			t.Errorf("Cron(%q) error: %v", test.expr, err)
+			continue
+		}
+		if got := c.Next(from).Format("2006-01-02 15:04"); got != test.want {
+			t.Errorf("Cron(%q).Next() = %v, want %v", test.expr, got, test.want)
+		}
+	}
+}
+
+// Leap days are only found every 4 years
+func TestCronLeapDay(t *testing.T) {
+	c, _ := Cron("0 0 29 2 *")
+	next := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	for _, want := range []int{2028, 2032, 2036} {
+		next = c.Next(next)
+		if next.Year() != want || next.Month() != time.February || next.Day() != 29 {
+			t.Errorf("Next() = %v, want February 29th %v", next, want)
+		}
+	}
+}
+
+func TestCronLocation(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	c, _ := Cron("0 9 * * *")
+	next := c.Next(time.Date(2024, time.January, 1, 10, 0, 0, 0, loc))
+	if want := time.Date(2024, time.January, 2, 9, 0, 0, 0, loc); !next.Equal(want) || next.Location() != loc {
+		t.Errorf("Next() = %v, want %v", next, want)
+	}
+}
+
+func TestCronInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"a * * * *",
+		"* * * foo *",
+		"@never",
+	} {
+		if _, err := Cron(expr); err == nil {
+			t.Errorf("Cron(%q) = nil error, want error", expr)
+		}
+	}
+}
+
+func TestEverySchedule(t *testing.T) {
+	from := time.Date(2024, time.January, 1, 10, 7, 30, 0, time.UTC)
+	if got := Every(90 * time.Second).Next(from); !got.Equal(from.Add(90 * time.Second)) {
+		t.Errorf("Every(90s).Next() = %v, want %v", got, from.Add(90*time.Second))
+	}
+}
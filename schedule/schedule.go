@@ -0,0 +1,278 @@
+// Package schedule runs jobs at fixed intervals, according to cron expressions or once after a delay,
+// like the "tick" and "boom" channels in the concurrency chapter, but for any number of jobs.
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/philippgille/hello-go/clock"
+)
+
+// Job is the work that's run by the Scheduler.
+// The context is cancelled when the Scheduler is stopped and the grace period is over.
+type Job func(ctx context.Context)
+
+// Overlap determines what happens when a job is due while its previous run is still running.
+type Overlap int
+
+const (
+	// Skip drops the run.
+	Skip Overlap = iota
+	// Queue runs it after the previous runs finished.
+	Queue
+	// Allow runs it at the same time in another goroutine.
+	Allow
+)
+
+// Scheduler runs jobs in their own goroutines.
+type Scheduler struct {
+	clock  clock.Clock
+	ctx    context.Context // Passed to the jobs
+	cancel context.CancelFunc
+	done   chan struct{} // Closed by Stop
+	loops  sync.WaitGroup
+	runs   sync.WaitGroup
+
+	mux     sync.Mutex
+	entries map[string]*entry
+}
+
+type entry struct {
+	name     string
+	schedule Schedule
+	overlap  Overlap
+	job      Job
+	first    time.Time // Time of the first run, if it's not determined by the schedule
+	remove   chan struct{}
+
+	// The following fields are protected by Scheduler.mux
+	next    time.Time
+	running int
+	queued  int
+}
+
+// New creates a Scheduler that uses the given clock, usually clock.Real.
+func New(c clock.Clock) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		clock:   c,
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		entries: make(map[string]*entry),
+	}
+}
+
+// Add registers a job with a name that must be unique.
+// Schedules created with Every must have a positive interval.
+func (s *Scheduler) Add(name string, schedule Schedule, overlap Overlap, job Job) error {
+	return s.add(name, schedule, overlap, job, time.Time{})
+}
+
+func (s *Scheduler) add(name string, schedule Schedule, overlap Overlap, job Job, first time.Time) error {
+	if e, ok := schedule.(every); ok && e <= 0 {
+		return fmt.Errorf("schedule: non-positive interval %v for job %q", time.Duration(e), name)
+	}
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.stopped() {
+		return fmt.Errorf("schedule: scheduler is stopped")
+	}
+	if _, ok := s.entries[name]; ok {
+		return fmt.Errorf("schedule: job %q already exists", name)
+	}
+	e := &entry{
+		name:     name,
+		schedule: schedule,
+		overlap:  overlap,
+		job:      job,
+		first:    first,
+		remove:   make(chan struct{}),
+	}
+	s.entries[name] = e
+	s.loops.Add(1)
+	go s.loop(e)
+	return nil
+}
+
+// AddCron registers a job that runs according to a cron expression, see Cron.
+func (s *Scheduler) AddCron(name, expr string, overlap Overlap, job Job) error {
+	schedule, err := Cron(expr)
+	if err != nil {
+		return err
+	}
+	return s.Add(name, schedule, overlap, job)
+}
+
+// Every registers a job that runs every d, starting d from now.
+// d must be positive.
+func (s *Scheduler) Every(name string, d time.Duration, overlap Overlap, job Job) error {
+	return s.Add(name, Every(d), overlap, job)
+}
+
+// After registers a job that runs once after d.
+// If d isn't positive, it runs right away.
+func (s *Scheduler) After(name string, d time.Duration, job Job) error {
+	at := s.clock.Now().Add(d)
+	return s.add(name, &once{at: at}, Allow, job, at)
+}
+
+// Remove unregisters a job. Runs that already started aren't stopped.
+func (s *Scheduler) Remove(name string) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	e, ok := s.entries[name]
+	if ok {
+		delete(s.entries, name)
+		close(e.remove)
+	}
+	return ok
+}
+
+// Next returns the time of the next run of the job.
+// ok is false if there's no such job or it won't run anymore.
+func (s *Scheduler) Next(name string) (next time.Time, ok bool) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	e, ok := s.entries[name]
+	if !ok || e.next.IsZero() {
+		return time.Time{}, false
+	}
+	return e.next, true
+}
+
+// Stop stops scheduling new runs and waits for the running jobs to return.
+// When ctx is done before that, the context of the jobs gets cancelled and ctx.Err() is returned.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mux.Lock()
+	select {
+	case <-s.done:
+	default:
+		close(s.done)
+	}
+	s.mux.Unlock()
+	s.loops.Wait()
+
+	finished := make(chan struct{})
+	go func() {
+		s.runs.Wait()
+		close(finished)
+	}()
+	defer s.cancel()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// loop waits for the next run time of the entry until there are no more runs, it's removed or the Scheduler is stopped
+func (s *Scheduler) loop(e *entry) {
+	defer s.loops.Done()
+	defer func() {
+		s.mux.Lock()
+		e.next = time.Time{}
+		// Only delete the entry if it wasn't removed and replaced by one with the same name
+		if s.entries[e.name] == e {
+			delete(s.entries, e.name)
+		}
+		s.mux.Unlock()
+	}()
+
+	// Run times are based on the previous run time instead of the time the timer fired, so they don't drift
+	last := s.clock.Now()
+	// Only the first run of a job added with After is known in advance. It runs even if it's already due.
+	next := e.first
+	for {
+		if next.IsZero() {
+			next = e.schedule.Next(last)
+			// Don't catch up on runs that were missed
+			if now := s.clock.Now(); !next.IsZero() && next.Before(now) {
+				next = e.schedule.Next(now)
+			}
+		}
+		s.mux.Lock()
+		e.next = next
+		s.mux.Unlock()
+		if next.IsZero() {
+			return
+		}
+
+		timer := s.clock.NewTimer(next.Sub(s.clock.Now()))
+		select {
+		case <-timer.C():
+			last, next = next, time.Time{}
+			s.start(e)
+		case <-e.remove:
+			timer.Stop()
+			return
+		case <-s.done:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// start runs the job according to the entry's overlap policy
+func (s *Scheduler) start(e *entry) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.stopped() {
+		return
+	}
+	if e.running > 0 {
+		switch e.overlap {
+		case Skip:
+			return
+		case Queue:
+			// The running goroutine picks it up when it's finished
+			e.queued++
+			return
+		}
+	}
+	e.running++
+	s.runs.Add(1)
+	go func() {
+		defer s.runs.Done()
+		for {
+			e.job(s.ctx)
+			s.mux.Lock()
+			if e.queued == 0 || s.stopped() {
+				e.queued = 0
+				e.running--
+				s.mux.Unlock()
+				return
+			}
+			e.queued--
+			s.mux.Unlock()
+		}
+	}()
+}
+
+// stopped reports whether Stop was called
+func (s *Scheduler) stopped() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// ========
+
+// once is a Schedule with a single run
+type once struct {
+	at time.Time
+}
+
+func (o *once) Next(t time.Time) time.Time {
+	if t.Before(o.at) {
+		return o.at
+	}
+	return time.Time{}
+}
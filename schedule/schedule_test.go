@@ -0,0 +1,354 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/philippgille/hello-go/clock"
+	"github.com/philippgille/hello-go/leakcheck"
+)
+
+var start = time.Date(2024, time.January, 1, 10, 7, 0, 0, time.UTC)
+
+// recorder is a Job that signals each start and then blocks until it's released
+type recorder struct {
+	started chan time.Time
+	release chan struct{}
+}
+
+func newRecorder() *recorder {
+	return &recorder{
+		started: make(chan time.Time, 100),
+		release: make(chan struct{}),
+	}
+}
+
+func (r *recorder) job(c clock.Clock) Job {
+	return func(ctx context.Context) {
+		r.started <- c.Now()
+		select {
+		case <-r.release:
+		case <-ctx.Done():
+		}
+	}
+}
+
+func receive(t *testing.T, c <-chan time.Time) time.Time {
+	t.Helper()
+	select {
+	case v := <-c:
+		return v
+	case <-time.After(time.Second):
+		t.Fatal("job didn't start")
+		return time.Time{}
+	}
+}
+
+// waitIdle waits until no run of the job is running anymore, which happens right after the job returned
+func waitIdle(t *testing.T, s *Scheduler, name string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		s.mux.Lock()
+		running := s.entries[name].running
+		s.mux.Unlock()
+		if running == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("job is still running")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestEvery(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	c := clock.NewFake(start)
+	s := New(c)
+	runs := make(chan time.Time, 10)
+	if err := s.Every("tick", time.Second, Skip, func(ctx context.Context) {
+		runs <- c.Now()
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 3; i++ {
+		c.BlockUntil(1)
+		want := start.Add(time.Duration(i) * time.Second)
+		if next, ok := s.Next("tick"); !ok || !next.Equal(want) {
+			t.Errorf("Next() = %v, %v, want %v, true", next, ok, want)
+		}
+		c.Advance(time.Second)
+		if got := receive(t, runs); !got.Equal(want) {
+			t.Errorf("run %v at %v, want %v", i, got, want)
+		}
+	}
+	if err := s.Stop(context.Background()); err != nil {
+		t.Error(err)
+	}
+	if _, ok := s.Next("tick"); ok {
+		t.Error("Next() is ok after Stop")
+	}
+}
+
+func TestAfter(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	c := clock.NewFake(start)
+	s := New(c)
+	r := newRecorder()
+	close(r.release)
+	s.After("boom", 5*time.Second, r.job(c))
+	c.BlockUntil(1)
+	if next, ok := s.Next("boom"); !ok || !next.Equal(start.Add(5*time.Second)) {
+		t.Errorf("Next() = %v, %v, want %v, true", next, ok, start.Add(5*time.Second))
+	}
+	c.Advance(4 * time.Second)
+	c.Advance(time.Second)
+	receive(t, r.started)
+	s.Stop(context.Background())
+	// Once is enough
+	if len(r.started) != 0 {
+		t.Errorf("job ran %v more times", len(r.started))
+	}
+	if _, ok := s.Next("boom"); ok {
+		t.Error("Next() is ok after the only run")
+	}
+}
+
+// Jobs that are already due must run right away, also with the real clock, where time passes before the loop starts
+func TestAfterDue(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	for _, c := range []clock.Clock{clock.NewFake(start), clock.Real} {
+		s := New(c)
+		runs := make(chan time.Time, 10)
+		durations := []time.Duration{-time.Second, 0, time.Nanosecond}
+		if c != clock.Real {
+			// The fake time doesn't pass by itself
+			durations = durations[:2]
+		}
+		for _, d := range durations {
+			if err := s.After(d.String(), d, func(ctx context.Context) {
+				runs <- time.Time{}
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for range durations {
+			receive(t, runs)
+		}
+		s.Stop(context.Background())
+	}
+}
+
+func TestSkip(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	c := clock.NewFake(start)
+	s := New(c)
+	r := newRecorder()
+	s.Every("job", time.Second, Skip, r.job(c))
+	c.BlockUntil(1)
+	c.Advance(time.Second)
+	receive(t, r.started)
+	// Due twice while the first run is still running.
+	// BlockUntil returns when the loop waits for the next run, after it handled the previous one.
+	for i := 0; i < 2; i++ {
+		c.BlockUntil(1)
+		c.Advance(time.Second)
+	}
+	c.BlockUntil(1)
+	r.release <- struct{}{}
+	waitIdle(t, s, "job")
+	// The next run after that isn't skipped
+	c.Advance(time.Second)
+	if got := receive(t, r.started); !got.Equal(start.Add(4 * time.Second)) {
+		t.Errorf("second run at %v, want %v", got, start.Add(4*time.Second))
+	}
+	close(r.release)
+	s.Stop(context.Background())
+	if len(r.started) != 0 {
+		t.Errorf("%v skipped runs started", len(r.started))
+	}
+}
+
+func TestQueue(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	c := clock.NewFake(start)
+	s := New(c)
+	r := newRecorder()
+	s.Every("job", time.Second, Queue, r.job(c))
+	c.BlockUntil(1)
+	c.Advance(time.Second)
+	receive(t, r.started)
+	for i := 0; i < 2; i++ {
+		c.BlockUntil(1)
+		c.Advance(time.Second)
+	}
+	c.BlockUntil(1)
+	if len(r.started) != 0 {
+		t.Fatal("queued run started while the previous one is running")
+	}
+	// Each release starts the next queued run
+	for i := 0; i < 2; i++ {
+		r.release <- struct{}{}
+		receive(t, r.started)
+	}
+	r.release <- struct{}{}
+	s.Stop(context.Background())
+	if len(r.started) != 0 {
+		t.Errorf("%v more runs than queued", len(r.started))
+	}
+}
+
+func TestAllow(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	c := clock.NewFake(start)
+	s := New(c)
+	r := newRecorder()
+	s.Every("job", time.Second, Allow, r.job(c))
+	// All three runs are running at the same time
+	for i := 1; i <= 3; i++ {
+		c.BlockUntil(1)
+		c.Advance(time.Second)
+		if got := receive(t, r.started); !got.Equal(start.Add(time.Duration(i) * time.Second)) {
+			t.Errorf("run %v at %v", i, got)
+		}
+	}
+	close(r.release)
+	s.Stop(context.Background())
+}
+
+func TestRemove(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	c := clock.NewFake(start)
+	s := New(c)
+	r := newRecorder()
+	s.Every("job", time.Second, Allow, r.job(c))
+	c.BlockUntil(1)
+	if !s.Remove("job") {
+		t.Error("Remove() = false, want true")
+	}
+	if s.Remove("job") {
+		t.Error("second Remove() = true, want false")
+	}
+	if _, ok := s.Next("job"); ok {
+		t.Error("Next() is ok after Remove")
+	}
+	c.Advance(time.Minute)
+	s.Stop(context.Background())
+	if len(r.started) != 0 {
+		t.Error("removed job ran")
+	}
+}
+
+func TestStop(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	c := clock.NewFake(start)
+	s := New(c)
+	r := newRecorder()
+	s.After("job", time.Second, r.job(c))
+	c.BlockUntil(1)
+	c.Advance(time.Second)
+	receive(t, r.started)
+
+	// Stop waits for the running job
+	stopped := make(chan error)
+	go func() {
+		stopped <- s.Stop(context.Background())
+	}()
+	select {
+	case <-stopped:
+		t.Fatal("Stop returned while the job is running")
+	case <-time.After(10 * time.Millisecond):
+	}
+	r.release <- struct{}{}
+	if err := <-stopped; err != nil {
+		t.Errorf("Stop() = %v, want nil", err)
+	}
+
+	if err := s.Every("late", time.Second, Skip, r.job(c)); err == nil {
+		t.Error("Every() after Stop = nil, want error")
+	}
+}
+
+func TestStopTimeout(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	c := clock.NewFake(start)
+	s := New(c)
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	s.After("job", 0, func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+		close(cancelled)
+	})
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	// The job is running, so Stop has to wait for it until the timeout
+	if err := s.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Stop() = %v, want %v", err, context.DeadlineExceeded)
+	}
+	// After the timeout the context of the job is cancelled
+	<-cancelled
+}
+
+func TestInvalid(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	s := New(clock.NewFake(start))
+	defer s.Stop(context.Background())
+	job := func(ctx context.Context) {}
+	for _, d := range []time.Duration{0, -time.Second} {
+		if err := s.Every("job", d, Skip, job); err == nil {
+			t.Errorf("Every(%v) = nil, want error", d)
+		}
+		if err := s.Add("job", Every(d), Skip, job); err == nil {
+			t.Errorf("Add(Every(%v)) = nil, want error", d)
+		}
+	}
+	if err := s.Every("job", time.Second, Skip, job); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Every("job", time.Second, Skip, job); err == nil {
+		t.Error("Every() with the same name = nil, want error")
+	}
+	if err := s.AddCron("cron", "* * *", Skip, job); err == nil {
+		t.Error("AddCron() with an invalid expression = nil, want error")
+	}
+}
+
+func TestAddCron(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	c := clock.NewFake(start)
+	s := New(c)
+	runs := make(chan time.Time, 10)
+	if err := s.AddCron("quarter", "*/15 * * * *", Skip, func(ctx context.Context) {
+		runs <- c.Now()
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"10:15", "10:30", "10:45", "11:00"} {
+		c.BlockUntil(1)
+		next, _ := s.Next("quarter")
+		if got := next.Format("15:04"); got != want {
+			t.Errorf("Next() = %v, want %v", got, want)
+		}
+		c.Advance(next.Sub(c.Now()))
+		if got := receive(t, runs).Format("15:04"); got != want {
+			t.Errorf("run at %v, want %v", got, want)
+		}
+	}
+	s.Stop(context.Background())
+}
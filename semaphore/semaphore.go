@@ -0,0 +1,111 @@
+// Package semaphore limits how many goroutines can use a resource at the same time.
+// Unlike a buffered channel, each goroutine can take a different share (weight) of the resource.
+package semaphore
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// Weighted is a semaphore with a total size that's shared by all holders.
+// Waiting goroutines are served in FIFO order, so a big request isn't starved by small ones.
+type Weighted struct {
+	size int64
+
+	mux     sync.Mutex
+	cur     int64
+	waiters list.List // Of *waiter
+}
+
+type waiter struct {
+	n     int64
+	ready chan struct{} // Closed when the weight was acquired
+}
+
+// NewWeighted creates a semaphore with the given total size.
+func NewWeighted(size int64) *Weighted {
+	return &Weighted{size: size}
+}
+
+// Acquire blocks until n can be acquired or ctx is done.
+// On success it returns nil, otherwise ctx.Err() and nothing is acquired.
+func (s *Weighted) Acquire(ctx context.Context, n int64) error {
+	s.mux.Lock()
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		s.mux.Unlock()
+		return nil
+	}
+	if n > s.size {
+		// Can never succeed, so just wait for the context
+		s.mux.Unlock()
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	w := &waiter{n: n, ready: make(chan struct{})}
+	elem := s.waiters.PushBack(w)
+	s.mux.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		s.mux.Lock()
+		defer s.mux.Unlock()
+		select {
+		case <-w.ready:
+			// Acquired after the context was done, so give it back
+			s.cur -= n
+			s.notify()
+		default:
+			front := s.waiters.Front() == elem
+			s.waiters.Remove(elem)
+			// If the removed waiter was blocking the ones behind it, they may be able to proceed now
+			if front {
+				s.notify()
+			}
+		}
+		return ctx.Err()
+	}
+}
+
+// TryAcquire acquires n without blocking and reports whether it succeeded.
+func (s *Weighted) TryAcquire(n int64) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		return true
+	}
+	return false
+}
+
+// Release gives back n.
+// It panics if more is released than was acquired.
+func (s *Weighted) Release(n int64) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.cur -= n
+	if s.cur < 0 {
+		panic("semaphore: released more than held")
+	}
+	s.notify()
+}
+
+// notify lets waiters acquire in FIFO order, as long as there's enough room. s.mux must be held.
+func (s *Weighted) notify() {
+	for {
+		front := s.waiters.Front()
+		if front == nil {
+			return
+		}
+		w := front.Value.(*waiter)
+		if s.size-s.cur < w.n {
+			return
+		}
+		s.cur += w.n
+		s.waiters.Remove(front)
+		close(w.ready)
+	}
+}
@@ -0,0 +1,142 @@
+package semaphore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/philippgille/hello-go/leakcheck"
+)
+
+// waitForWaiters waits until n goroutines wait in Acquire
+func waitForWaiters(t *testing.T, s *Weighted, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		s.mux.Lock()
+		l := s.waiters.Len()
+		s.mux.Unlock()
+		if l == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%v waiters, want %v", l, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// acquire calls Acquire in a new goroutine and returns the channel that receives its result
+func acquire(ctx context.Context, s *Weighted, n int64) <-chan error {
+	c := make(chan error, 1)
+	go func() {
+		c <- s.Acquire(ctx, n)
+	}()
+	return c
+}
+
+func blocked(c <-chan error) bool {
+	select {
+	case <-c:
+		return false
+	case <-time.After(10 * time.Millisecond):
+		return true
+	}
+}
+
+func TestTryAcquire(t *testing.T) {
+	s := NewWeighted(3)
+	if !s.TryAcquire(2) || !s.TryAcquire(1) {
+		t.Fatal("TryAcquire() = false with enough room")
+	}
+	if s.TryAcquire(1) {
+		t.Error("TryAcquire() = true when full")
+	}
+	s.Release(3)
+	if s.TryAcquire(4) {
+		t.Error("TryAcquire() = true for more than the size")
+	}
+}
+
+// A big request in front isn't overtaken by small ones, even if they would fit
+func TestFIFO(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	s := NewWeighted(10)
+	s.Acquire(context.Background(), 10)
+	big := acquire(context.Background(), s, 10)
+	waitForWaiters(t, s, 1)
+	small := acquire(context.Background(), s, 1)
+	waitForWaiters(t, s, 2)
+
+	s.Release(1)
+	if !blocked(small) {
+		t.Fatal("small request overtook the big one")
+	}
+	if s.TryAcquire(1) {
+		t.Error("TryAcquire() overtook the waiting requests")
+	}
+	s.Release(9)
+	if err := <-big; err != nil {
+		t.Fatal(err)
+	}
+	if !blocked(small) {
+		t.Fatal("small request acquired while the big one holds everything")
+	}
+	s.Release(10)
+	if err := <-small; err != nil {
+		t.Fatal(err)
+	}
+	s.Release(1)
+}
+
+// When the waiter in front gives up, the ones behind it that fit get their turn
+func TestCancelFront(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	s := NewWeighted(10)
+	s.Acquire(context.Background(), 5)
+	ctx, cancel := context.WithCancel(context.Background())
+	front := acquire(ctx, s, 10)
+	waitForWaiters(t, s, 1)
+	behind := acquire(context.Background(), s, 5)
+	waitForWaiters(t, s, 2)
+	if !blocked(behind) {
+		t.Fatal("request overtook the one in front")
+	}
+
+	cancel()
+	if err := <-front; !errors.Is(err, context.Canceled) {
+		t.Errorf("Acquire() = %v, want %v", err, context.Canceled)
+	}
+	if err := <-behind; err != nil {
+		t.Errorf("Acquire() behind the cancelled one = %v, want nil", err)
+	}
+	// Nothing is left over from the cancelled request
+	s.Release(10)
+	if !s.TryAcquire(10) {
+		t.Error("TryAcquire(10) = false after releasing everything")
+	}
+}
+
+func TestAcquireTooBig(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	s := NewWeighted(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Acquire(ctx, 2); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Acquire() = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestReleaseTooMuch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Release() of more than held didn't panic")
+		}
+	}()
+	s := NewWeighted(1)
+	s.Release(1)
+}
@@ -0,0 +1,300 @@
+// Package shape provides 2D shapes that implement the Shape interface,
+// like "myFloat2" and "vertex2" implement "abser" in the methods chapter.
+// Points are vectors of the vector package.
+package shape
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/philippgille/hello-go/vector"
+)
+
+// Point is a point in 2D space.
+type Point = vector.Vec2[float64]
+
+// Shape is implemented by Circle, Rectangle, Triangle and Polygon.
+// Points on the edge of a shape count as contained.
+type Shape interface {
+	Area() float64
+	Perimeter() float64
+	BoundingBox() Rectangle
+	Contains(p Point) bool
+}
+
+// TotalArea sums up the areas of any kind of shapes.
+func TotalArea(shapes []Shape) float64 {
+	total := 0.0
+	for _, s := range shapes {
+		total += s.Area()
+	}
+	return total
+}
+
+// ========
+
+// Circle is a circle around Center.
+type Circle struct {
+	Center Point
+	Radius float64
+}
+
+// Area implements Shape.
+func (c Circle) Area() float64 {
+	return math.Pi * c.Radius * c.Radius
+}
+
+// Perimeter implements Shape.
+func (c Circle) Perimeter() float64 {
+	return 2 * math.Pi * c.Radius
+}
+
+// BoundingBox implements Shape.
+func (c Circle) BoundingBox() Rectangle {
+	r := Point{X: c.Radius, Y: c.Radius}
+	return Rectangle{c.Center.Sub(r), c.Center.Add(r)}
+}
+
+// Contains implements Shape.
+func (c Circle) Contains(p Point) bool {
+	return c.Center.Distance(p) <= c.Radius
+}
+
+func (c Circle) String() string {
+	return fmt.Sprintf("circle around %v with radius %v", c.Center, c.Radius)
+}
+
+// ========
+
+// Rectangle is an axis-aligned rectangle with the lower left corner Min and the upper right corner Max.
+type Rectangle struct {
+	Min, Max Point
+}
+
+// Area implements Shape.
+func (r Rectangle) Area() float64 {
+	return (r.Max.X - r.Min.X) * (r.Max.Y - r.Min.Y)
+}
+
+// Perimeter implements Shape.
+func (r Rectangle) Perimeter() float64 {
+	return 2 * ((r.Max.X - r.Min.X) + (r.Max.Y - r.Min.Y))
+}
+
+// BoundingBox implements Shape.
+func (r Rectangle) BoundingBox() Rectangle {
+	return r
+}
+
+// Contains implements Shape.
+func (r Rectangle) Contains(p Point) bool {
+	return r.Min.X <= p.X && p.X <= r.Max.X && r.Min.Y <= p.Y && p.Y <= r.Max.Y
+}
+
+func (r Rectangle) String() string {
+	return fmt.Sprintf("rectangle from %v to %v", r.Min, r.Max)
+}
+
+// Polygon returns the corners of the rectangle, counterclockwise.
+func (r Rectangle) Polygon() Polygon {
+	return Polygon{r.Min, {X: r.Max.X, Y: r.Min.Y}, r.Max, {X: r.Min.X, Y: r.Max.Y}}
+}
+
+// ========
+
+// Triangle is a triangle with the corners A, B and C.
+type Triangle struct {
+	A, B, C Point
+}
+
+// Area implements Shape.
+func (t Triangle) Area() float64 {
+	return t.Polygon().Area()
+}
+
+// Perimeter implements Shape.
+func (t Triangle) Perimeter() float64 {
+	return t.Polygon().Perimeter()
+}
+
+// BoundingBox implements Shape.
+func (t Triangle) BoundingBox() Rectangle {
+	return t.Polygon().BoundingBox()
+}
+
+// Contains implements Shape.
+func (t Triangle) Contains(p Point) bool {
+	return t.Polygon().Contains(p)
+}
+
+func (t Triangle) String() string {
+	return fmt.Sprintf("triangle %v %v %v", t.A, t.B, t.C)
+}
+
+// Polygon returns the corners of the triangle.
+func (t Triangle) Polygon() Polygon {
+	return Polygon{t.A, t.B, t.C}
+}
+
+// ========
+
+// Polygon is a simple (not self-intersecting) polygon with the given corners.
+// The last corner is connected to the first one.
+type Polygon []Point
+
+// Area implements Shape, using the shoelace formula.
+func (p Polygon) Area() float64 {
+	sum := 0.0
+	for i, a := range p {
+		sum += a.Cross(p[(i+1)%len(p)])
+	}
+	return math.Abs(sum) / 2
+}
+
+// Perimeter implements Shape.
+func (p Polygon) Perimeter() float64 {
+	sum := 0.0
+	for i, a := range p {
+		sum += a.Distance(p[(i+1)%len(p)])
+	}
+	return sum
+}
+
+// BoundingBox implements Shape.
+func (p Polygon) BoundingBox() Rectangle {
+	if len(p) == 0 {
+		return Rectangle{}
+	}
+	r := Rectangle{p[0], p[0]}
+	for _, c := range p[1:] {
+		r.Min.X = math.Min(r.Min.X, c.X)
+		r.Min.Y = math.Min(r.Min.Y, c.Y)
+		r.Max.X = math.Max(r.Max.X, c.X)
+		r.Max.Y = math.Max(r.Max.Y, c.Y)
+	}
+	return r
+}
+
+// Contains implements Shape, by counting how often a ray from p to the right crosses an edge.
+func (p Polygon) Contains(pt Point) bool {
+	inside := false
+	for i, a := range p {
+		b := p[(i+1)%len(p)]
+		if onSegment(pt, a, b) {
+			return true
+		}
+		if (a.Y > pt.Y) != (b.Y > pt.Y) {
+			x := a.X + (pt.Y-a.Y)*(b.X-a.X)/(b.Y-a.Y)
+			if pt.X < x {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// Polygon returns p, so Polygon has the same method as the other shapes with corners.
+func (p Polygon) Polygon() Polygon {
+	return p
+}
+
+// ========
+
+// polygoner is implemented by all shapes with corners
+type polygoner interface {
+	Polygon() Polygon
+}
+
+// Intersects reports whether the shapes overlap or touch.
+// It works for all combinations of the shapes of this package, also as pointers.
+// For other implementations of Shape it only compares the bounding boxes.
+func Intersects(a, b Shape) bool {
+	// Quick check first
+	if !a.BoundingBox().overlaps(b.BoundingBox()) {
+		return false
+	}
+	switch a := deref(a).(type) {
+	case Circle:
+		switch b := deref(b).(type) {
+		case Circle:
+			return a.Center.Distance(b.Center) <= a.Radius+b.Radius
+		case polygoner:
+			return circlePolygon(a, b.Polygon())
+		}
+	case polygoner:
+		switch b := deref(b).(type) {
+		case Circle:
+			return circlePolygon(b, a.Polygon())
+		case polygoner:
+			return polygonPolygon(a.Polygon(), b.Polygon())
+		}
+	}
+	// Unknown shape, so the overlapping bounding boxes are all we know
+	return true
+}
+
+// deref turns a *Circle into a Circle.
+// Pointers to the other shapes don't need it, because they're polygoners as well.
+func deref(s Shape) Shape {
+	if c, ok := s.(*Circle); ok {
+		return *c
+	}
+	return s
+}
+
+func (r Rectangle) overlaps(o Rectangle) bool {
+	return r.Min.X <= o.Max.X && o.Min.X <= r.Max.X && r.Min.Y <= o.Max.Y && o.Min.Y <= r.Max.Y
+}
+
+func circlePolygon(c Circle, p Polygon) bool {
+	if p.Contains(c.Center) {
+		return true
+	}
+	for i, a := range p {
+		if distanceToSegment(c.Center, a, p[(i+1)%len(p)]) <= c.Radius {
+			return true
+		}
+	}
+	return false
+}
+
+func polygonPolygon(p, q Polygon) bool {
+	for i, a := range p {
+		b := p[(i+1)%len(p)]
+		for j, c := range q {
+			if segmentsIntersect(a, b, c, q[(j+1)%len(q)]) {
+				return true
+			}
+		}
+	}
+	// No edges cross, so either one is inside the other or they're apart
+	return len(p) > 0 && len(q) > 0 && (q.Contains(p[0]) || p.Contains(q[0]))
+}
+
+// distanceToSegment returns the distance between p and the segment from a to b
+func distanceToSegment(p, a, b Point) float64 {
+	ab := b.Sub(a)
+	l := ab.Dot(ab)
+	if l == 0 {
+		return p.Distance(a)
+	}
+	// Position of the closest point on the segment, between 0 (a) and 1 (b)
+	t := math.Max(0, math.Min(1, p.Sub(a).Dot(ab)/l))
+	return p.Distance(a.Lerp(b, t))
+}
+
+func onSegment(p, a, b Point) bool {
+	return distanceToSegment(p, a, b) <= 1e-12
+}
+
+func segmentsIntersect(a, b, c, d Point) bool {
+	d1 := b.Sub(a).Cross(c.Sub(a))
+	d2 := b.Sub(a).Cross(d.Sub(a))
+	d3 := d.Sub(c).Cross(a.Sub(c))
+	d4 := d.Sub(c).Cross(b.Sub(c))
+	if ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) && ((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0)) {
+		return true
+	}
+	// Touching or collinear
+	return onSegment(c, a, b) || onSegment(d, a, b) || onSegment(a, c, d) || onSegment(b, c, d)
+}
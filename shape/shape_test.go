@@ -0,0 +1,129 @@
+package shape
+
+import (
+	"math"
+	"testing"
+)
+
+var (
+	unitCircle = Circle{Center: Point{X: 0, Y: 0}, Radius: 1}
+	square     = Rectangle{Min: Point{X: 0, Y: 0}, Max: Point{X: 2, Y: 2}}
+	triangle   = Triangle{A: Point{X: 0, Y: 0}, B: Point{X: 4, Y: 0}, C: Point{X: 0, Y: 3}}
+	// An L shape, so the corner at (1, 1) is concave
+	lShape = Polygon{{X: 0, Y: 0}, {X: 2, Y: 0}, {X: 2, Y: 1}, {X: 1, Y: 1}, {X: 1, Y: 2}, {X: 0, Y: 2}}
+)
+
+func TestAreaPerimeter(t *testing.T) {
+	tests := []struct {
+		s               Shape
+		area, perimeter float64
+	}{
+		{unitCircle, math.Pi, 2 * math.Pi},
+		{square, 4, 8},
+		{triangle, 6, 12},
+		{lShape, 3, 8},
+		{Polygon{}, 0, 0},
+	}
+	for _, test := range tests {
+		if a := test.s.Area(); math.Abs(a-test.area) > 1e-9 {
+			t.Errorf("%v: Area() = %v, want %v", test.s, a, test.area)
+		}
+		if p := test.s.Perimeter(); math.Abs(p-test.perimeter) > 1e-9 {
+			t.Errorf("%v: Perimeter() = %v, want %v", test.s, p, test.perimeter)
+		}
+	}
+	if a := TotalArea([]Shape{square, triangle}); a != 10 {
+		t.Errorf("TotalArea() = %v, want 10", a)
+	}
+}
+
+func TestContains(t *testing.T) {
+	tests := []struct {
+		s      Shape
+		p      Point
+		inside bool
+	}{
+		{unitCircle, Point{X: 0, Y: 0}, true},
+		{unitCircle, Point{X: 0, Y: -1}, true}, // Edge
+		{unitCircle, Point{X: 0.8, Y: 0.8}, false},
+		{square, Point{X: 1, Y: 1}, true},
+		{square, Point{X: 2, Y: 2}, true}, // Corner
+		{square, Point{X: 0, Y: 1}, true}, // Edge
+		{square, Point{X: 2.1, Y: 1}, false},
+		{triangle, Point{X: 1, Y: 1}, true},
+		{triangle, Point{X: 2, Y: 1.5}, true}, // Hypotenuse
+		{triangle, Point{X: 4, Y: 0}, true},   // Corner
+		{triangle, Point{X: 3, Y: 2}, false},
+		{lShape, Point{X: 0.5, Y: 1.5}, true},
+		{lShape, Point{X: 1, Y: 1}, true},      // Concave corner
+		{lShape, Point{X: 1.5, Y: 1}, true},    // Edge next to the concave corner
+		{lShape, Point{X: 1.5, Y: 1.5}, false}, // In the notch
+		{lShape, Point{X: 2, Y: 0.5}, true},    // Right edge
+		{Polygon{}, Point{}, false},
+	}
+	for _, test := range tests {
+		if got := test.s.Contains(test.p); got != test.inside {
+			t.Errorf("%v: Contains(%v) = %v, want %v", test.s, test.p, got, test.inside)
+		}
+	}
+}
+
+// box is a Shape that isn't part of this package and has no Polygon method
+type box struct {
+	r Rectangle
+}
+
+func (b box) Area() float64          { return b.r.Area() }
+func (b box) Perimeter() float64     { return b.r.Perimeter() }
+func (b box) BoundingBox() Rectangle { return b.r }
+func (b box) Contains(p Point) bool  { return b.r.Contains(p) }
+
+func TestIntersects(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Shape
+		want bool
+	}{
+		{"overlapping circles", unitCircle, Circle{Point{X: 1, Y: 1}, 1}, true},
+		{"touching circles", unitCircle, Circle{Point{X: 2, Y: 0}, 1}, true},
+		{"separate circles", unitCircle, Circle{Point{X: 2.1, Y: 0}, 1}, false},
+		{"circle inside circle", unitCircle, Circle{Point{X: 0.1, Y: 0}, 0.1}, true},
+		{"circle and square", unitCircle, square, true},
+		{"circle touching square", unitCircle, Rectangle{Point{X: 1, Y: -1}, Point{X: 2, Y: 1}}, true},
+		// The bounding boxes overlap, but the circle doesn't reach the corner
+		{"circle near corner", unitCircle, Rectangle{Point{X: 0.8, Y: 0.8}, Point{X: 2, Y: 2}}, false},
+		{"circle inside triangle", Circle{Point{X: 1, Y: 1}, 0.5}, triangle, true},
+		{"triangle around circle", triangle, Circle{Point{X: 1, Y: 1}, 0.5}, true},
+		{"square and triangle", square, triangle, true},
+		{"square inside triangle", Rectangle{Point{X: 0.5, Y: 0.5}, Point{X: 1, Y: 1}}, triangle, true},
+		{"touching squares", square, Rectangle{Point{X: 2, Y: 2}, Point{X: 3, Y: 3}}, true},
+		{"separate squares", square, Rectangle{Point{X: 2.5, Y: 0}, Point{X: 3, Y: 1}}, false},
+		{"square in the notch", lShape, Rectangle{Point{X: 1.2, Y: 1.2}, Point{X: 1.8, Y: 1.8}}, false},
+		{"triangle in the notch", lShape, Triangle{Point{X: 1.2, Y: 1.2}, Point{X: 1.8, Y: 1.2}, Point{X: 1.8, Y: 1.8}}, false},
+		{"circle in the notch", lShape, Circle{Point{X: 1.6, Y: 1.6}, 0.7}, true},
+		{"pointer circles", &unitCircle, &Circle{Point{X: 2.1, Y: 0}, 1}, false},
+		{"pointer circle and square", &square, &unitCircle, true},
+		{"pointer square and triangle", &square, &triangle, true},
+		{"pointer square in the notch", &lShape, &Rectangle{Point{X: 1.2, Y: 1.2}, Point{X: 1.8, Y: 1.8}}, false},
+		// Unknown shapes are compared by their bounding boxes
+		{"unknown shapes", box{square}, box{Rectangle{Point{X: 1, Y: 1}, Point{X: 3, Y: 3}}}, true},
+		{"unknown and circle", unitCircle, box{Rectangle{Point{X: 0.8, Y: 0.8}, Point{X: 2, Y: 2}}}, true},
+		{"separate unknown", box{square}, box{square.shift(5)}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Intersects(test.a, test.b); got != test.want {
+				t.Errorf("Intersects(%v, %v) = %v, want %v", test.a, test.b, got, test.want)
+			}
+			if got := Intersects(test.b, test.a); got != test.want {
+				t.Errorf("Intersects(%v, %v) = %v, want %v", test.b, test.a, got, test.want)
+			}
+		})
+	}
+}
+
+// shift moves r to the right
+func (r Rectangle) shift(x float64) Rectangle {
+	d := Point{X: x}
+	return Rectangle{r.Min.Add(d), r.Max.Add(d)}
+}
@@ -0,0 +1,256 @@
+// Package solve finds roots of functions, like "MySqrt" in the flowcontrol chapter finds the root of z² - x with Newton's method.
+// Unlike the "for z != old" loop of MySqrt, all methods stop after a maximum number of iterations,
+// so they can't loop forever when the values oscillate.
+package solve
+
+import (
+	"errors"
+	"math"
+)
+
+// Errors of the methods. The Result is returned with them, so the last approximation can still be used.
+var (
+	ErrNoConvergence  = errors.New("solve: no convergence within the maximum number of iterations")
+	ErrNoBracket      = errors.New("solve: f(a) and f(b) must have different signs")
+	ErrZeroDerivative = errors.New("solve: derivative is zero")
+)
+
+// Func is a function of which a root is searched.
+type Func func(x float64) float64
+
+// Options configure the methods. The zero value uses the defaults.
+type Options struct {
+	// Tol is the tolerance for the root.
+	// It's absolute for roots between -1 and 1 and relative for larger roots.
+	// Default: 1e-12
+	Tol float64
+	// MaxIter is the maximum number of iterations. Default: 100
+	MaxIter int
+}
+
+func (o Options) withDefaults() Options {
+	if o.Tol <= 0 {
+		o.Tol = 1e-12
+	}
+	if o.MaxIter <= 0 {
+		o.MaxIter = 100
+	}
+	return o
+}
+
+// tolAt returns the absolute tolerance at x
+func (o Options) tolAt(x float64) float64 {
+	return o.Tol * math.Max(1, math.Abs(x))
+}
+
+// Result is the result of a method.
+type Result struct {
+	Root       float64
+	Iterations int
+	// Converged is true if the tolerance was reached or f(Root) is 0.
+	Converged bool
+	// Estimate is an estimate of the absolute error of Root:
+	// the last step for Newton and secant, half of the last bracket for bisection and Brent.
+	Estimate float64
+}
+
+// ========
+
+// Newton uses Newton's method, starting at x0.
+// If df (the derivative of f) is nil, it's approximated with central differences.
+// It converges quickly close to a root, but can diverge or oscillate for a bad x0.
+func Newton(f, df Func, x0 float64, opts Options) (Result, error) {
+	opts = opts.withDefaults()
+	if df == nil {
+		df = derivative(f)
+	}
+	res := Result{Root: x0, Estimate: math.Inf(1)}
+	for res.Iterations < opts.MaxIter {
+		fx := f(res.Root)
+		if fx == 0 {
+			res.Converged, res.Estimate = true, 0
+			return res, nil
+		}
+		d := df(res.Root)
+		if d == 0 {
+			return res, ErrZeroDerivative
+		}
+		res.Iterations++
+		step := fx / d
+		res.Root -= step
+		res.Estimate = math.Abs(step)
+		if res.Estimate <= opts.tolAt(res.Root) {
+			res.Converged = true
+			return res, nil
+		}
+	}
+	return res, ErrNoConvergence
+}
+
+// derivative approximates the derivative of f with central differences
+func derivative(f Func) Func {
+	return func(x float64) float64 {
+		// The cube root of the machine epsilon balances rounding and approximation errors
+		h := 6e-6 * math.Max(1, math.Abs(x))
+		return (f(x+h) - f(x-h)) / (2 * h)
+	}
+}
+
+// Secant uses the secant method, starting with x0 and x1.
+// It's like Newton with a derivative from the last two points, so it needs no derivative.
+func Secant(f Func, x0, x1 float64, opts Options) (Result, error) {
+	opts = opts.withDefaults()
+	f0, f1 := f(x0), f(x1)
+	res := Result{Root: x1, Estimate: math.Abs(x1 - x0)}
+	for res.Iterations < opts.MaxIter {
+		if f1 == 0 {
+			res.Converged, res.Estimate = true, 0
+			return res, nil
+		}
+		if f1 == f0 {
+			return res, ErrZeroDerivative
+		}
+		res.Iterations++
+		step := f1 * (x1 - x0) / (f1 - f0)
+		x0, f0 = x1, f1
+		x1 -= step
+		f1 = f(x1)
+		res.Root, res.Estimate = x1, math.Abs(step)
+		if res.Estimate <= opts.tolAt(x1) {
+			res.Converged = true
+			return res, nil
+		}
+	}
+	return res, ErrNoConvergence
+}
+
+// ========
+
+// Bisection halves the interval [a, b] until it's smaller than the tolerance.
+// f(a) and f(b) must have different signs, then it always converges, but slowly.
+func Bisection(f Func, a, b float64, opts Options) (Result, error) {
+	opts = opts.withDefaults()
+	fa, fb := f(a), f(b)
+	if res, ok := bracketEnd(a, fa, b, fb); ok {
+		return res, nil
+	}
+	if sameSign(fa, fb) {
+		return Result{Root: math.NaN(), Estimate: math.Inf(1)}, ErrNoBracket
+	}
+	res := Result{}
+	for res.Iterations < opts.MaxIter {
+		res.Iterations++
+		m := a + (b-a)/2
+		res.Root, res.Estimate = m, math.Abs(b-a)/2
+		fm := f(m)
+		// m == a or m == b means there's no float between a and b anymore
+		if fm == 0 || res.Estimate <= opts.tolAt(m) || m == a || m == b {
+			res.Converged = true
+			return res, nil
+		}
+		if sameSign(fa, fm) {
+			a, fa = m, fm
+		} else {
+			b = m
+		}
+	}
+	return res, ErrNoConvergence
+}
+
+// Brent uses Brent's method, which combines bisection, the secant method and inverse quadratic interpolation.
+// Like Bisection it needs f(a) and f(b) with different signs and always converges, but usually much faster.
+func Brent(f Func, a, b float64, opts Options) (Result, error) {
+	opts = opts.withDefaults()
+	fa, fb := f(a), f(b)
+	if res, ok := bracketEnd(a, fa, b, fb); ok {
+		return res, nil
+	}
+	if sameSign(fa, fb) {
+		return Result{Root: math.NaN(), Estimate: math.Inf(1)}, ErrNoBracket
+	}
+
+	// b is the best approximation, a the previous one and c the other end of the bracket [b, c].
+	// d is the last step and e the one before.
+	c, fc := b, fb
+	var d, e float64
+	res := Result{}
+	for res.Iterations < opts.MaxIter {
+		res.Iterations++
+		if sameSign(fb, fc) {
+			c, fc = a, fa
+			d = b - a
+			e = d
+		}
+		if math.Abs(fc) < math.Abs(fb) {
+			a, b, c = b, c, b
+			fa, fb, fc = fb, fc, fb
+		}
+		tol := 2*epsilon*math.Abs(b) + opts.tolAt(b)/2
+		m := (c - b) / 2
+		res.Root, res.Estimate = b, math.Abs(m)
+		if math.Abs(m) <= tol || fb == 0 {
+			res.Converged = true
+			return res, nil
+		}
+
+		if math.Abs(e) >= tol && math.Abs(fa) > math.Abs(fb) {
+			// Interpolation
+			var p, q float64
+			s := fb / fa
+			if a == c {
+				// Secant
+				p = 2 * m * s
+				q = 1 - s
+			} else {
+				// Inverse quadratic interpolation
+				q = fa / fc
+				r := fb / fc
+				p = s * (2*m*q*(q-r) - (b-a)*(r-1))
+				q = (q - 1) * (r - 1) * (s - 1)
+			}
+			if p > 0 {
+				q = -q
+			}
+			p = math.Abs(p)
+			if 2*p < math.Min(3*m*q-math.Abs(tol*q), math.Abs(e*q)) {
+				e = d
+				d = p / q
+			} else {
+				// Interpolation would be worse than bisection
+				d = m
+				e = d
+			}
+		} else {
+			// Bisection
+			d = m
+			e = d
+		}
+
+		a, fa = b, fb
+		if math.Abs(d) > tol {
+			b += d
+		} else {
+			b += math.Copysign(tol, m)
+		}
+		fb = f(b)
+	}
+	return res, ErrNoConvergence
+}
+
+const epsilon = 2.220446049250313e-16 // Difference between 1 and the next float64
+
+// bracketEnd returns a converged result if a or b is a root already
+func bracketEnd(a, fa, b, fb float64) (Result, bool) {
+	switch {
+	case fa == 0:
+		return Result{Root: a, Converged: true}, true
+	case fb == 0:
+		return Result{Root: b, Converged: true}, true
+	}
+	return Result{}, false
+}
+
+// sameSign compares the signs without multiplying, which could overflow
+func sameSign(x, y float64) bool {
+	return (x > 0) == (y > 0)
+}
@@ -0,0 +1,160 @@
+package solve
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func sqrt2(x float64) float64 {
+	return x*x - 2
+}
+
+// cubic has a single real root at about -1.7693, but Newton's method starting at 0 oscillates between 0 and 1
+func cubic(x float64) float64 {
+	return x*x*x - 2*x + 2
+}
+
+const cubicRoot = -1.7692923542386314
+
+func TestNewton(t *testing.T) {
+	tests := []struct {
+		name string
+		f    Func
+		df   Func
+		x0   float64
+		want float64
+	}{
+		{"sqrt2", sqrt2, func(x float64) float64 { return 2 * x }, 1, math.Sqrt2},
+		{"sqrt2 without derivative", sqrt2, nil, 1, math.Sqrt2},
+		{"negative root", sqrt2, nil, -1, -math.Sqrt2},
+		{"cubic", cubic, nil, -2, cubicRoot},
+		{"cos", math.Cos, nil, 1, math.Pi / 2},
+		{"exact start", func(x float64) float64 { return x - 2 }, nil, 2, 2},
+		// The approximated derivative would be 0 here, because x±h is rounded away next to 1e20
+		{"large root", func(x float64) float64 { return x - 1e20 }, func(float64) float64 { return 1 }, 1, 1e20},
+	}
+	for _, test := range tests {
+		res, err := Newton(test.f, test.df, test.x0, Options{})
+		if err != nil || !res.Converged {
+			t.Errorf("%v: Newton() = %+v, %v", test.name, res, err)
+			continue
+		}
+		if math.Abs(res.Root-test.want) > 1e-9*math.Max(1, math.Abs(test.want)) {
+			t.Errorf("%v: Newton() = %v, want %v", test.name, res.Root, test.want)
+		}
+	}
+}
+
+func TestNewtonErrors(t *testing.T) {
+	// Oscillation between 0 and 1, which would loop forever with "for z != old"
+	res, err := Newton(cubic, func(x float64) float64 { return 3*x*x - 2 }, 0, Options{MaxIter: 50})
+	if !errors.Is(err, ErrNoConvergence) || res.Converged || res.Iterations != 50 {
+		t.Errorf("Newton() of an oscillating function = %+v, %v, want %v after 50 iterations", res, err, ErrNoConvergence)
+	}
+	if res.Root != 0 || res.Estimate != 1 {
+		t.Errorf("last approximation = %v with estimate %v, want 0 and 1", res.Root, res.Estimate)
+	}
+	// The default is 100 iterations
+	if res, err := Newton(cubic, nil, 0, Options{}); !errors.Is(err, ErrNoConvergence) || res.Iterations != 100 {
+		t.Errorf("Newton() with default options = %+v, %v", res, err)
+	}
+
+	// No root, and a flat start
+	res, err = Newton(func(x float64) float64 { return x*x + 1 }, func(x float64) float64 { return 2 * x }, 0, Options{})
+	if !errors.Is(err, ErrZeroDerivative) || res.Root != 0 {
+		t.Errorf("Newton() with zero derivative = %+v, %v, want %v", res, err, ErrZeroDerivative)
+	}
+	// No root at all: x² + 1 diverges chaotically
+	if _, err := Newton(func(x float64) float64 { return x*x + 1 }, nil, 0.5, Options{}); !errors.Is(err, ErrNoConvergence) {
+		t.Errorf("Newton() without a root = %v, want %v", err, ErrNoConvergence)
+	}
+}
+
+func TestSecant(t *testing.T) {
+	res, err := Secant(sqrt2, 1, 2, Options{})
+	if err != nil || math.Abs(res.Root-math.Sqrt2) > 1e-12 {
+		t.Errorf("Secant() = %+v, %v, want %v", res, err, math.Sqrt2)
+	}
+	res, err = Secant(cubic, -3, -2, Options{})
+	if err != nil || math.Abs(res.Root-cubicRoot) > 1e-12 {
+		t.Errorf("Secant() = %+v, %v, want %v", res, err, cubicRoot)
+	}
+	// Symmetric points have the same value, so the secant is flat
+	if _, err := Secant(sqrt2, -1, 1, Options{}); !errors.Is(err, ErrZeroDerivative) {
+		t.Errorf("Secant() with a flat secant = %v, want %v", err, ErrZeroDerivative)
+	}
+	if _, err := Secant(cubic, 0, 0.1, Options{MaxIter: 3}); !errors.Is(err, ErrNoConvergence) {
+		t.Errorf("Secant() with 3 iterations = %v, want %v", err, ErrNoConvergence)
+	}
+}
+
+func TestBracketing(t *testing.T) {
+	methods := []struct {
+		name string
+		f    func(Func, float64, float64, Options) (Result, error)
+	}{
+		{"Bisection", Bisection},
+		{"Brent", Brent},
+	}
+	tests := []struct {
+		name string
+		f    Func
+		a, b float64
+		want float64
+	}{
+		{"sqrt2", sqrt2, 0, 2, math.Sqrt2},
+		{"reversed", sqrt2, 2, 0, math.Sqrt2},
+		{"cubic", cubic, -3, 0, cubicRoot},
+		{"cos", math.Cos, 0, 3, math.Pi / 2},
+		{"root at a", func(x float64) float64 { return x - 1 }, 1, 3, 1},
+		{"root at b", func(x float64) float64 { return x - 3 }, 0, 3, 3},
+		// A steep step, where interpolation doesn't help
+		{"step", func(x float64) float64 { return math.Atan(1e6 * (x - 0.3)) }, -1, 1, 0.3},
+		{"large root", func(x float64) float64 { return x - 1e20 }, 0, 1e21, 1e20},
+	}
+	for _, m := range methods {
+		for _, test := range tests {
+			res, err := m.f(test.f, test.a, test.b, Options{MaxIter: 200})
+			if err != nil || !res.Converged {
+				t.Errorf("%v %v: %+v, %v", m.name, test.name, res, err)
+				continue
+			}
+			if math.Abs(res.Root-test.want) > 1e-11*math.Max(1, math.Abs(test.want)) {
+				t.Errorf("%v %v = %v, want %v", m.name, test.name, res.Root, test.want)
+			}
+			if res.Estimate > 1e-11*math.Max(1, math.Abs(test.want)) {
+				t.Errorf("%v %v: Estimate = %v", m.name, test.name, res.Estimate)
+			}
+		}
+
+		if res, err := m.f(sqrt2, 2, 3, Options{}); !errors.Is(err, ErrNoBracket) || !math.IsNaN(res.Root) {
+			t.Errorf("%v without a sign change = %+v, %v, want %v", m.name, res, err, ErrNoBracket)
+		}
+		if _, err := m.f(math.Cos, 0, 3, Options{MaxIter: 2}); !errors.Is(err, ErrNoConvergence) {
+			t.Errorf("%v with 2 iterations = %v, want %v", m.name, err, ErrNoConvergence)
+		}
+	}
+
+	// Brent needs far fewer iterations
+	bisection, _ := Bisection(math.Cos, 0, 3, Options{})
+	brent, _ := Brent(math.Cos, 0, 3, Options{})
+	if brent.Iterations >= bisection.Iterations {
+		t.Errorf("Brent took %v iterations, bisection %v", brent.Iterations, bisection.Iterations)
+	}
+}
+
+// The tolerance is relative for large roots, so a tolerance below the float64 spacing still converges
+func TestTolerance(t *testing.T) {
+	res, err := Bisection(func(x float64) float64 { return x - 1e20 }, 0, 1e21, Options{Tol: 1e-300, MaxIter: 2000})
+	if err != nil || res.Root != 1e20 {
+		t.Errorf("Bisection() = %+v, %v", res, err)
+	}
+	res, err = Newton(sqrt2, nil, 1, Options{Tol: 1e-3})
+	if err != nil || math.Abs(res.Root-math.Sqrt2) > 1e-3 {
+		t.Errorf("Newton() = %+v, %v", res, err)
+	}
+	if precise, _ := Newton(sqrt2, nil, 1, Options{}); res.Iterations >= precise.Iterations {
+		t.Errorf("Newton() with a large tolerance took %v iterations, with the default %v", res.Iterations, precise.Iterations)
+	}
+}
@@ -0,0 +1,170 @@
+// Package tracing records what goroutines do (spans of work, channel sends and receives, waiting for mutexes)
+// and exports it in the Chrome Trace Event format, which can be opened in https://ui.perfetto.dev or chrome://tracing,
+// or as a text timeline.
+//
+// All methods can be called on a nil *Tracer, in which case they don't record anything,
+// so instrumented code doesn't need to check whether tracing is enabled.
+package tracing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/philippgille/hello-go/internal/goid"
+)
+
+// Event is a trace event in the Chrome Trace Event format.
+type Event struct {
+	Name     string            `json:"name"`
+	Category string            `json:"cat"`
+	Phase    string            `json:"ph"`            // "X" for spans, "i" for instant events
+	Time     float64           `json:"ts"`            // Microseconds since the Tracer was created
+	Duration float64           `json:"dur,omitempty"` // Microseconds, only for spans
+	PID      int               `json:"pid"`
+	TID      uint64            `json:"tid"` // The goroutine ID
+	Scope    string            `json:"s,omitempty"`
+	Args     map[string]string `json:"args,omitempty"`
+}
+
+// Tracer records events. It's safe for concurrent use.
+type Tracer struct {
+	start time.Time
+
+	mux    sync.Mutex
+	events []Event
+}
+
+// New creates a Tracer that starts recording immediately.
+func New() *Tracer {
+	return &Tracer{start: time.Now()}
+}
+
+// Span starts a span of work in the current goroutine and returns the function that ends it.
+//
+//	defer t.Span("work")()
+func (t *Tracer) Span(name string) (end func()) {
+	return t.span(name, "span")
+}
+
+// Go runs f in a new goroutine, recorded as span.
+func (t *Tracer) Go(name string, f func()) {
+	go func() {
+		defer t.Span(name)()
+		f()
+	}()
+}
+
+// Instant records an event without a duration in the current goroutine.
+func (t *Tracer) Instant(name, category string) {
+	if t == nil {
+		return
+	}
+	t.add(Event{
+		Name:     name,
+		Category: category,
+		Phase:    "i",
+		Time:     t.since(time.Now()),
+		TID:      goid.Current(),
+		Scope:    "t",
+	})
+}
+
+// Lock locks l and records the time it took to get the lock as span with the category "mutex".
+func (t *Tracer) Lock(l sync.Locker, name string) {
+	end := t.span("wait "+name, "mutex")
+	l.Lock()
+	end()
+}
+
+// Send sends v on c and records the time it was blocked as span with the category "chan".
+// It's a function instead of a method, because methods can't have type parameters.
+func Send[T any](t *Tracer, c chan<- T, v T, name string) {
+	end := t.span("send "+name, "chan")
+	c <- v
+	end()
+}
+
+// Recv receives from c and records the time it was blocked as span with the category "chan".
+func Recv[T any](t *Tracer, c <-chan T, name string) (T, bool) {
+	end := t.span("recv "+name, "chan")
+	v, ok := <-c
+	end()
+	return v, ok
+}
+
+// Events returns a copy of the recorded events, ordered by time.
+func (t *Tracer) Events() []Event {
+	if t == nil {
+		return nil
+	}
+	t.mux.Lock()
+	events := append([]Event(nil), t.events...)
+	t.mux.Unlock()
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time < events[j].Time
+	})
+	return events
+}
+
+// WriteJSON writes the events in the Chrome Trace Event format.
+func (t *Tracer) WriteJSON(w io.Writer) error {
+	events := t.Events()
+	if events == nil {
+		events = []Event{}
+	}
+	return json.NewEncoder(w).Encode(struct {
+		TraceEvents     []Event `json:"traceEvents"`
+		DisplayTimeUnit string  `json:"displayTimeUnit"`
+	}{events, "ms"})
+}
+
+// WriteTimeline writes one line per event, like:
+//
+//	12.345ms  g7     send c (0.021ms)
+func (t *Tracer) WriteTimeline(w io.Writer) error {
+	for _, e := range t.Events() {
+		line := fmt.Sprintf("%10.3fms  g%-5d %s", e.Time/1000, e.TID, e.Name)
+		if e.Phase == "X" {
+			line += fmt.Sprintf(" (%.3fms)", e.Duration/1000)
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ========
+
+func (t *Tracer) span(name, category string) func() {
+	if t == nil {
+		return func() {}
+	}
+	start := time.Now()
+	tid := goid.Current()
+	return func() {
+		t.add(Event{
+			Name:     name,
+			Category: category,
+			Phase:    "X",
+			Time:     t.since(start),
+			Duration: float64(time.Since(start).Nanoseconds()) / 1000,
+			TID:      tid,
+		})
+	}
+}
+
+func (t *Tracer) add(e Event) {
+	t.mux.Lock()
+	t.events = append(t.events, e)
+	t.mux.Unlock()
+}
+
+// since returns the microseconds between the start of the Tracer and ts
+func (t *Tracer) since(ts time.Time) float64 {
+	return float64(ts.Sub(t.start).Nanoseconds()) / 1000
+}
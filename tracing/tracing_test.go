@@ -0,0 +1,139 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/philippgille/hello-go/internal/goid"
+)
+
+func TestWriteJSON(t *testing.T) {
+	tr := New()
+	end := tr.Span("main")
+	time.Sleep(time.Millisecond)
+	done := make(chan struct{})
+	tr.Go("worker", func() {
+		tr.Instant("tick", "test")
+		close(done)
+	})
+	<-done
+	// The span of the worker ends right after f returned
+	for len(tr.Events()) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	var mux sync.Mutex
+	tr.Lock(&mux, "m")
+	mux.Unlock()
+	end()
+
+	var buf bytes.Buffer
+	if err := tr.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var trace struct {
+		TraceEvents []map[string]interface{} `json:"traceEvents"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &trace); err != nil {
+		t.Fatal(err)
+	}
+	events := map[string]map[string]interface{}{}
+	for _, e := range trace.TraceEvents {
+		events[e["name"].(string)] = e
+	}
+	if len(events) != 4 {
+		t.Fatalf("got events %v, want main, worker, tick and wait m", trace.TraceEvents)
+	}
+
+	self := float64(goid.Current())
+	main := events["main"]
+	if main["ph"] != "X" || main["cat"] != "span" || main["tid"] != self {
+		t.Errorf("main = %v, want span of goroutine %v", main, self)
+	}
+	if dur, ok := main["dur"].(float64); !ok || dur < 1000 {
+		t.Errorf("main dur = %v, want at least 1000 microseconds", main["dur"])
+	}
+	if ts, ok := main["ts"].(float64); !ok || ts < 0 {
+		t.Errorf("main ts = %v", main["ts"])
+	}
+
+	worker, tick := events["worker"], events["tick"]
+	if worker["ph"] != "X" || worker["tid"] == self {
+		t.Errorf("worker = %v, want span of another goroutine", worker)
+	}
+	// Instant events have a scope, but no duration
+	if tick["ph"] != "i" || tick["cat"] != "test" || tick["tid"] != worker["tid"] || tick["s"] != "t" || tick["dur"] != nil {
+		t.Errorf("tick = %v, want instant event of the worker goroutine", tick)
+	}
+	if tick["ts"].(float64) < worker["ts"].(float64) {
+		t.Errorf("tick at %v before the start of the worker at %v", tick["ts"], worker["ts"])
+	}
+	if m := events["wait m"]; m["ph"] != "X" || m["cat"] != "mutex" || m["tid"] != self {
+		t.Errorf("wait m = %v, want mutex span", m)
+	}
+}
+
+func TestSendRecv(t *testing.T) {
+	tr := New()
+	c := make(chan int, 1)
+	Send(tr, c, 42, "c")
+	if v, ok := Recv(tr, c, "c"); v != 42 || !ok {
+		t.Errorf("Recv() = %v, %v, want 42, true", v, ok)
+	}
+	close(c)
+	if _, ok := Recv(tr, c, "c"); ok {
+		t.Error("Recv() of a closed channel is ok")
+	}
+	var names []string
+	for _, e := range tr.Events() {
+		if e.Category != "chan" {
+			t.Errorf("event %v has category %q, want chan", e.Name, e.Category)
+		}
+		names = append(names, e.Name)
+	}
+	if got := strings.Join(names, ","); got != "send c,recv c,recv c" {
+		t.Errorf("events %v, want send c, recv c, recv c", got)
+	}
+}
+
+func TestWriteTimeline(t *testing.T) {
+	tr := New()
+	tr.Span("work")()
+	tr.Instant("tick", "test")
+	var buf bytes.Buffer
+	if err := tr.WriteTimeline(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], " work (") || !strings.HasSuffix(lines[1], " tick") {
+		t.Errorf("timeline:\n%s", buf.String())
+	}
+}
+
+// All methods work on a nil Tracer without recording anything
+func TestNil(t *testing.T) {
+	var tr *Tracer
+	tr.Span("work")()
+	tr.Instant("tick", "test")
+	done := make(chan struct{})
+	tr.Go("worker", func() {
+		close(done)
+	})
+	<-done
+	var mux sync.Mutex
+	tr.Lock(&mux, "m")
+	mux.Unlock()
+	c := make(chan int, 1)
+	Send(tr, c, 1, "c")
+	Recv(tr, c, "c")
+	if events := tr.Events(); events != nil {
+		t.Errorf("Events() = %v, want nil", events)
+	}
+	var buf bytes.Buffer
+	if err := tr.WriteJSON(&buf); err != nil || !strings.Contains(buf.String(), `"traceEvents":[]`) {
+		t.Errorf("WriteJSON() = %v, wrote %s", err, buf.String())
+	}
+}
@@ -0,0 +1,223 @@
+// Package vector provides generic 2D and 3D vectors,
+// like the "vertex" type in the methods chapter, but with all the usual vector math.
+package vector
+
+import (
+	"fmt"
+	"math"
+)
+
+// Float is float32 or float64.
+type Float interface {
+	~float32 | ~float64
+}
+
+// Vec2 is a 2D vector.
+type Vec2[T Float] struct {
+	X, Y T
+}
+
+// Vec3 is a 3D vector.
+type Vec3[T Float] struct {
+	X, Y, Z T
+}
+
+// ========
+
+// Add returns v+w.
+func (v Vec2[T]) Add(w Vec2[T]) Vec2[T] {
+	return Vec2[T]{v.X + w.X, v.Y + w.Y}
+}
+
+// Sub returns v-w.
+func (v Vec2[T]) Sub(w Vec2[T]) Vec2[T] {
+	return Vec2[T]{v.X - w.X, v.Y - w.Y}
+}
+
+// Scale returns v*f.
+// Unlike "vertex.scale" it returns a new vector instead of modifying v.
+func (v Vec2[T]) Scale(f T) Vec2[T] {
+	return Vec2[T]{v.X * f, v.Y * f}
+}
+
+// Dot returns the dot product.
+func (v Vec2[T]) Dot(w Vec2[T]) T {
+	return v.X*w.X + v.Y*w.Y
+}
+
+// Cross returns the z component of the 3D cross product,
+// which is positive if w is counterclockwise from v.
+func (v Vec2[T]) Cross(w Vec2[T]) T {
+	return v.X*w.Y - v.Y*w.X
+}
+
+// Len returns the length, like "vertex.abs".
+func (v Vec2[T]) Len() T {
+	return T(math.Hypot(float64(v.X), float64(v.Y)))
+}
+
+// Normalize returns the vector with the same direction and length 1.
+// The zero vector stays the zero vector, because it has no direction.
+func (v Vec2[T]) Normalize() Vec2[T] {
+	l := v.Len()
+	if l == 0 {
+		return v
+	}
+	return Vec2[T]{v.X / l, v.Y / l}
+}
+
+// Distance returns the distance between the points v and w.
+func (v Vec2[T]) Distance(w Vec2[T]) T {
+	return v.Sub(w).Len()
+}
+
+// Lerp interpolates linearly between v (t=0) and w (t=1).
+func (v Vec2[T]) Lerp(w Vec2[T], t T) Vec2[T] {
+	return Vec2[T]{v.X + (w.X-v.X)*t, v.Y + (w.Y-v.Y)*t}
+}
+
+// Angle returns the angle between v and w in radians, between 0 and π.
+// It's 0 if one of them is the zero vector.
+func (v Vec2[T]) Angle(w Vec2[T]) T {
+	return angle[T](float64(v.Dot(w)), float64(v.Len()), float64(w.Len()))
+}
+
+// Rotate returns v rotated counterclockwise around the origin by the angle in radians.
+func (v Vec2[T]) Rotate(radians T) Vec2[T] {
+	sin, cos := math.Sincos(float64(radians))
+	x, y := float64(v.X), float64(v.Y)
+	return Vec2[T]{T(x*cos - y*sin), T(x*sin + y*cos)}
+}
+
+// Project returns the projection of v onto w.
+// It's the zero vector if w is the zero vector.
+func (v Vec2[T]) Project(w Vec2[T]) Vec2[T] {
+	d := w.Dot(w)
+	if d == 0 {
+		return Vec2[T]{}
+	}
+	return w.Scale(v.Dot(w) / d)
+}
+
+// Equal reports whether all components differ by at most epsilon.
+func (v Vec2[T]) Equal(w Vec2[T], epsilon T) bool {
+	return near(v.X, w.X, epsilon) && near(v.Y, w.Y, epsilon)
+}
+
+// String implements fmt.Stringer.
+func (v Vec2[T]) String() string {
+	return fmt.Sprintf("(%v, %v)", v.X, v.Y)
+}
+
+// ========
+
+// Add returns v+w.
+func (v Vec3[T]) Add(w Vec3[T]) Vec3[T] {
+	return Vec3[T]{v.X + w.X, v.Y + w.Y, v.Z + w.Z}
+}
+
+// Sub returns v-w.
+func (v Vec3[T]) Sub(w Vec3[T]) Vec3[T] {
+	return Vec3[T]{v.X - w.X, v.Y - w.Y, v.Z - w.Z}
+}
+
+// Scale returns v*f.
+func (v Vec3[T]) Scale(f T) Vec3[T] {
+	return Vec3[T]{v.X * f, v.Y * f, v.Z * f}
+}
+
+// Dot returns the dot product.
+func (v Vec3[T]) Dot(w Vec3[T]) T {
+	return v.X*w.X + v.Y*w.Y + v.Z*w.Z
+}
+
+// Cross returns the cross product, which is perpendicular to v and w.
+func (v Vec3[T]) Cross(w Vec3[T]) Vec3[T] {
+	return Vec3[T]{
+		v.Y*w.Z - v.Z*w.Y,
+		v.Z*w.X - v.X*w.Z,
+		v.X*w.Y - v.Y*w.X,
+	}
+}
+
+// Len returns the length.
+// Like Vec2.Len it uses math.Hypot, so it doesn't overflow for large components, unlike the square root of v.Dot(v).
+func (v Vec3[T]) Len() T {
+	return T(math.Hypot(math.Hypot(float64(v.X), float64(v.Y)), float64(v.Z)))
+}
+
+// Normalize returns the vector with the same direction and length 1.
+// The zero vector stays the zero vector, because it has no direction.
+func (v Vec3[T]) Normalize() Vec3[T] {
+	l := v.Len()
+	if l == 0 {
+		return v
+	}
+	return Vec3[T]{v.X / l, v.Y / l, v.Z / l}
+}
+
+// Distance returns the distance between the points v and w.
+func (v Vec3[T]) Distance(w Vec3[T]) T {
+	return v.Sub(w).Len()
+}
+
+// Lerp interpolates linearly between v (t=0) and w (t=1).
+func (v Vec3[T]) Lerp(w Vec3[T], t T) Vec3[T] {
+	return Vec3[T]{v.X + (w.X-v.X)*t, v.Y + (w.Y-v.Y)*t, v.Z + (w.Z-v.Z)*t}
+}
+
+// Angle returns the angle between v and w in radians, between 0 and π.
+// It's 0 if one of them is the zero vector.
+func (v Vec3[T]) Angle(w Vec3[T]) T {
+	return angle[T](float64(v.Dot(w)), float64(v.Len()), float64(w.Len()))
+}
+
+// Rotate returns v rotated around the axis by the angle in radians (counterclockwise when the axis points towards the viewer),
+// using Rodrigues' rotation formula.
+// v is returned unchanged if the axis is the zero vector.
+func (v Vec3[T]) Rotate(axis Vec3[T], radians T) Vec3[T] {
+	k := axis.Normalize()
+	if k == (Vec3[T]{}) {
+		return v
+	}
+	sin, cos := math.Sincos(float64(radians))
+	// v*cos + (k×v)*sin + k*(k·v)*(1-cos)
+	return v.Scale(T(cos)).
+		Add(k.Cross(v).Scale(T(sin))).
+		Add(k.Scale(k.Dot(v) * T(1-cos)))
+}
+
+// Project returns the projection of v onto w.
+// It's the zero vector if w is the zero vector.
+func (v Vec3[T]) Project(w Vec3[T]) Vec3[T] {
+	d := w.Dot(w)
+	if d == 0 {
+		return Vec3[T]{}
+	}
+	return w.Scale(v.Dot(w) / d)
+}
+
+// Equal reports whether all components differ by at most epsilon.
+func (v Vec3[T]) Equal(w Vec3[T], epsilon T) bool {
+	return near(v.X, w.X, epsilon) && near(v.Y, w.Y, epsilon) && near(v.Z, w.Z, epsilon)
+}
+
+// String implements fmt.Stringer.
+func (v Vec3[T]) String() string {
+	return fmt.Sprintf("(%v, %v, %v)", v.X, v.Y, v.Z)
+}
+
+// ========
+
+func angle[T Float](dot, lenV, lenW float64) T {
+	if lenV == 0 || lenW == 0 {
+		return 0
+	}
+	// Rounding errors can lead to values slightly outside of [-1, 1], for which Acos returns NaN
+	cos := math.Max(-1, math.Min(1, dot/(lenV*lenW)))
+	return T(math.Acos(cos))
+}
+
+func near[T Float](a, b, epsilon T) bool {
+	return math.Abs(float64(a-b)) <= float64(epsilon)
+}
@@ -0,0 +1,147 @@
+package vector
+
+import (
+	"math"
+	"testing"
+)
+
+const epsilon = 1e-9
+
+func TestVec2(t *testing.T) {
+	v := Vec2[float64]{3, 4}
+	w := Vec2[float64]{-1, 2}
+	zero := Vec2[float64]{}
+	tests := []struct {
+		name      string
+		got, want Vec2[float64]
+	}{
+		{"Add", v.Add(w), Vec2[float64]{2, 6}},
+		{"Sub", v.Sub(w), Vec2[float64]{4, 2}},
+		{"Scale", v.Scale(2), Vec2[float64]{6, 8}},
+		{"Normalize", v.Normalize(), Vec2[float64]{0.6, 0.8}},
+		{"Normalize zero", zero.Normalize(), zero},
+		{"Lerp 0", v.Lerp(w, 0), v},
+		{"Lerp 0.5", v.Lerp(w, 0.5), Vec2[float64]{1, 3}},
+		{"Lerp 1", v.Lerp(w, 1), w},
+		{"Rotate 90°", v.Rotate(math.Pi / 2), Vec2[float64]{-4, 3}},
+		{"Rotate 360°", v.Rotate(2 * math.Pi), v},
+		{"Rotate zero", zero.Rotate(1), zero},
+		{"Project", v.Project(Vec2[float64]{2, 0}), Vec2[float64]{3, 0}},
+		{"Project onto zero", v.Project(zero), zero},
+		{"Project zero", zero.Project(v), zero},
+	}
+	for _, test := range tests {
+		if !test.got.Equal(test.want, epsilon) {
+			t.Errorf("%v = %v, want %v", test.name, test.got, test.want)
+		}
+	}
+
+	scalars := []struct {
+		name      string
+		got, want float64
+	}{
+		{"Dot", v.Dot(w), 5},
+		{"Cross", v.Cross(w), 10},
+		{"Cross counterclockwise", Vec2[float64]{1, 0}.Cross(Vec2[float64]{0, 1}), 1},
+		{"Cross clockwise", Vec2[float64]{0, 1}.Cross(Vec2[float64]{1, 0}), -1},
+		{"Len", v.Len(), 5},
+		{"Len zero", zero.Len(), 0},
+		{"Distance", v.Distance(w), math.Sqrt(20)},
+		{"Angle", Vec2[float64]{1, 0}.Angle(Vec2[float64]{0, 1}), math.Pi / 2},
+		{"Angle opposite", v.Angle(v.Scale(-1)), math.Pi},
+		{"Angle same", v.Angle(v.Scale(3)), 0},
+		{"Angle zero", v.Angle(zero), 0},
+		{"Angle of zero", zero.Angle(zero), 0},
+	}
+	for _, test := range scalars {
+		if !near(test.got, test.want, epsilon) {
+			t.Errorf("%v = %v, want %v", test.name, test.got, test.want)
+		}
+	}
+}
+
+func TestVec3(t *testing.T) {
+	v := Vec3[float64]{1, 2, 2}
+	w := Vec3[float64]{0, -1, 3}
+	x := Vec3[float64]{1, 0, 0}
+	z := Vec3[float64]{0, 0, 1}
+	zero := Vec3[float64]{}
+	tests := []struct {
+		name      string
+		got, want Vec3[float64]
+	}{
+		{"Add", v.Add(w), Vec3[float64]{1, 1, 5}},
+		{"Sub", v.Sub(w), Vec3[float64]{1, 3, -1}},
+		{"Scale", v.Scale(-1), Vec3[float64]{-1, -2, -2}},
+		{"Cross", x.Cross(Vec3[float64]{0, 1, 0}), z},
+		{"Cross parallel", v.Cross(v.Scale(2)), zero},
+		{"Cross zero", v.Cross(zero), zero},
+		{"Normalize", v.Normalize(), Vec3[float64]{1.0 / 3, 2.0 / 3, 2.0 / 3}},
+		{"Normalize zero", zero.Normalize(), zero},
+		{"Lerp 0.5", v.Lerp(w, 0.5), Vec3[float64]{0.5, 0.5, 2.5}},
+		{"Rotate around z", x.Rotate(z, math.Pi/2), Vec3[float64]{0, 1, 0}},
+		{"Rotate around itself", x.Rotate(x, 1), x},
+		{"Rotate around zero axis", v.Rotate(zero, 1), v},
+		{"Project", v.Project(z.Scale(5)), Vec3[float64]{0, 0, 2}},
+		{"Project onto zero", v.Project(zero), zero},
+	}
+	for _, test := range tests {
+		if !test.got.Equal(test.want, epsilon) {
+			t.Errorf("%v = %v, want %v", test.name, test.got, test.want)
+		}
+	}
+
+	scalars := []struct {
+		name      string
+		got, want float64
+	}{
+		{"Dot", v.Dot(w), 4},
+		{"Len", v.Len(), 3},
+		{"Len zero", zero.Len(), 0},
+		{"Distance", v.Distance(w), math.Sqrt(11)},
+		{"Angle", x.Angle(z), math.Pi / 2},
+		{"Angle zero", v.Angle(zero), 0},
+	}
+	for _, test := range scalars {
+		if !near(test.got, test.want, epsilon) {
+			t.Errorf("%v = %v, want %v", test.name, test.got, test.want)
+		}
+	}
+
+	// The rotation keeps the length
+	if l := v.Rotate(w, 1.234).Len(); !near(l, 3, epsilon) {
+		t.Errorf("Len() after Rotate = %v, want 3", l)
+	}
+}
+
+// The length of large vectors doesn't overflow, even though the squares of their components do
+func TestLenOverflow(t *testing.T) {
+	big64 := 1e200
+	if l := (Vec2[float64]{3 * big64, 4 * big64}).Len(); !near(l/big64, 5, epsilon) {
+		t.Errorf("Vec2.Len() = %v, want 5e200", l)
+	}
+	if l := (Vec3[float64]{big64, 2 * big64, 2 * big64}).Len(); !near(l/big64, 3, epsilon) {
+		t.Errorf("Vec3.Len() = %v, want 3e200", l)
+	}
+	big32 := float32(1e30)
+	if l := (Vec3[float32]{big32, 2 * big32, 2 * big32}).Len(); !near(l/big32, 3, 1e-6) {
+		t.Errorf("Vec3[float32].Len() = %v, want 3e30", l)
+	}
+	// And small ones don't underflow to 0
+	if l := (Vec3[float64]{1e-200, 2e-200, 2e-200}).Len(); l == 0 {
+		t.Error("Vec3.Len() of a tiny vector = 0")
+	}
+}
+
+func TestEqual(t *testing.T) {
+	v := Vec2[float32]{1, 2}
+	if !v.Equal(Vec2[float32]{1.05, 1.95}, 0.1) {
+		t.Error("Equal() = false within epsilon")
+	}
+	if v.Equal(Vec2[float32]{1.2, 2}, 0.1) {
+		t.Error("Equal() = true outside of epsilon")
+	}
+	if s := (Vec3[float64]{1, 2.5, -3}).String(); s != "(1, 2.5, -3)" {
+		t.Errorf("String() = %q", s)
+	}
+}
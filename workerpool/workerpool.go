@@ -0,0 +1,214 @@
+// Package workerpool runs tasks on a limited number of goroutines,
+// instead of starting one goroutine per task like "myMutex" in the concurrency chapter does.
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// ErrClosed is returned by Submit when the Pool is closed.
+var ErrClosed = errors.New("workerpool: pool is closed")
+
+// Task is the work that's submitted to a Pool.
+// The context is done when the context passed to Submit is done, or when Close gives up waiting.
+type Task[R any] func(ctx context.Context) (R, error)
+
+// Result is the outcome of a Task.
+type Result[R any] struct {
+	Value R
+	Err   error
+}
+
+// Options configure a Pool.
+type Options struct {
+	// Workers is the number of workers that are always running.
+	// Defaults to runtime.GOMAXPROCS(0).
+	Workers int
+	// MaxWorkers enables elastic scaling: When the queue is full, up to MaxWorkers workers are started.
+	// The additional ones stop after being idle for IdleTimeout.
+	// Values below Workers mean a fixed number of workers.
+	MaxWorkers int
+	// IdleTimeout defaults to one second.
+	IdleTimeout time.Duration
+	// QueueSize is the number of tasks that can wait for a worker before Submit blocks.
+	QueueSize int
+}
+
+// Pool runs tasks on its workers.
+type Pool[R any] struct {
+	opts   Options
+	queue  chan job[R]
+	ctx    context.Context // Cancelled when Close gives up
+	cancel context.CancelFunc
+	done   chan struct{} // Closed by Close, to unblock Submit
+	wg     sync.WaitGroup
+	once   sync.Once // For closing "done"
+
+	sendMux sync.RWMutex // Held while sending to the queue, so it isn't closed during a send
+	closed  bool
+
+	mux     sync.Mutex
+	workers int
+}
+
+type job[R any] struct {
+	ctx    context.Context
+	task   Task[R]
+	result chan Result[R]
+}
+
+// New starts a Pool.
+func New[R any](opts Options) *Pool[R] {
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.GOMAXPROCS(0)
+	}
+	if opts.MaxWorkers < opts.Workers {
+		opts.MaxWorkers = opts.Workers
+	}
+	if opts.IdleTimeout <= 0 {
+		opts.IdleTimeout = time.Second
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pool[R]{
+		opts:   opts,
+		queue:  make(chan job[R], opts.QueueSize),
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	for i := 0; i < opts.Workers; i++ {
+		p.startWorker(false)
+	}
+	return p
+}
+
+// Submit queues a task and returns a channel that receives its Result.
+// It blocks while the queue is full, until ctx is done (returning ctx.Err()) or the Pool is closed (returning ErrClosed).
+func (p *Pool[R]) Submit(ctx context.Context, task Task[R]) (<-chan Result[R], error) {
+	p.sendMux.RLock()
+	defer p.sendMux.RUnlock()
+	if p.closed {
+		return nil, ErrClosed
+	}
+	j := job[R]{ctx, task, make(chan Result[R], 1)}
+
+	// Try without blocking first, to know whether an elastic worker is needed
+	select {
+	case p.queue <- j:
+		return j.result, nil
+	default:
+	}
+	p.mux.Lock()
+	if p.workers < p.opts.MaxWorkers {
+		p.startWorker(true)
+	}
+	p.mux.Unlock()
+
+	select {
+	case p.queue <- j:
+		return j.result, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-p.done:
+		return nil, ErrClosed
+	}
+}
+
+// Close stops accepting tasks and waits for the queued and running tasks to finish.
+// When ctx is done before that, the contexts of the tasks are cancelled, queued tasks fail with
+// context.Canceled, and ctx.Err() is returned after the workers returned.
+func (p *Pool[R]) Close(ctx context.Context) error {
+	// Submit waits for room in the queue while it holds sendMux.RLock, so it must see "done"
+	// before the write lock below can be taken. Otherwise Close would wait for a full queue forever.
+	p.once.Do(func() {
+		close(p.done)
+	})
+	p.sendMux.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.sendMux.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		<-finished
+		return ctx.Err()
+	}
+}
+
+// Workers returns the number of running workers.
+func (p *Pool[R]) Workers() int {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	return p.workers
+}
+
+// startWorker must be called with p.mux held (or during New)
+func (p *Pool[R]) startWorker(elastic bool) {
+	p.workers++
+	p.wg.Add(1)
+	go p.work(elastic)
+}
+
+func (p *Pool[R]) work(elastic bool) {
+	defer p.wg.Done()
+	var idle <-chan time.Time
+	var timer *time.Timer
+	if elastic {
+		timer = time.NewTimer(p.opts.IdleTimeout)
+		defer timer.Stop()
+		idle = timer.C
+	}
+	for {
+		select {
+		case j, ok := <-p.queue:
+			if !ok {
+				p.mux.Lock()
+				p.workers--
+				p.mux.Unlock()
+				return
+			}
+			// Submit made room for exactly this result, so the worker doesn't wait for callers that never read it
+			j.result <- p.run(j)
+			if elastic {
+				timer.Reset(p.opts.IdleTimeout)
+			}
+		case <-idle:
+			p.mux.Lock()
+			p.workers--
+			p.mux.Unlock()
+			return
+		}
+	}
+}
+
+// run runs the task with a context that's done when either the submitter's or the pool's context is done
+func (p *Pool[R]) run(j job[R]) Result[R] {
+	ctx, cancel := context.WithCancel(j.ctx)
+	defer cancel()
+	stop := context.AfterFunc(p.ctx, cancel)
+	defer stop()
+	// AfterFunc cancels in its own goroutine, so the pool's context is checked directly as well
+	if err := ctx.Err(); err != nil {
+		return Result[R]{Err: err}
+	}
+	if err := p.ctx.Err(); err != nil {
+		return Result[R]{Err: err}
+	}
+	v, err := j.task(ctx)
+	return Result[R]{v, err}
+}
@@ -0,0 +1,241 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/philippgille/hello-go/leakcheck"
+)
+
+// blockingTask returns a task that signals its start and returns its value when it's released or its context is done
+func blockingTask(v int, started chan<- int, release <-chan struct{}) Task[int] {
+	return func(ctx context.Context) (int, error) {
+		started <- v
+		select {
+		case <-release:
+			return v, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+func waitForWorkers(t *testing.T, p *Pool[int], n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for p.Workers() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Workers() = %v, want %v", p.Workers(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestResults(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	p := New[int](Options{Workers: 3})
+	errOdd := errors.New("odd")
+	var results []<-chan Result[int]
+	for i := 0; i < 10; i++ {
+		r, err := p.Submit(context.Background(), func(ctx context.Context) (int, error) {
+			if i%2 == 1 {
+				return 0, errOdd
+			}
+			return i * i, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, r)
+	}
+	for i, c := range results {
+		r := <-c
+		if i%2 == 1 && !errors.Is(r.Err, errOdd) || i%2 == 0 && (r.Err != nil || r.Value != i*i) {
+			t.Errorf("task %v: %+v", i, r)
+		}
+	}
+	if err := p.Close(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
+
+// Submit blocks while the queue is full
+func TestBackpressure(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	p := New[int](Options{Workers: 1, QueueSize: 1})
+	started := make(chan int, 10)
+	release := make(chan struct{})
+	p.Submit(context.Background(), blockingTask(1, started, release))
+	<-started
+	// Fills the queue
+	if _, err := p.Submit(context.Background(), blockingTask(2, started, release)); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.Submit(ctx, blockingTask(3, started, release)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Submit() to a full queue = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// A blocked Submit returns when the pool is closed
+	submitted := make(chan error)
+	go func() {
+		_, err := p.Submit(context.Background(), blockingTask(4, started, release))
+		submitted <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	closed := make(chan error)
+	go func() {
+		closed <- p.Close(context.Background())
+	}()
+	if err := <-submitted; !errors.Is(err, ErrClosed) {
+		t.Errorf("blocked Submit() = %v, want %v", err, ErrClosed)
+	}
+	close(release)
+	if err := <-closed; err != nil {
+		t.Error(err)
+	}
+	// Only the first two tasks ran
+	if len(started) != 1 || <-started != 2 {
+		t.Error("rejected tasks ran")
+	}
+}
+
+func TestElastic(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	p := New[int](Options{Workers: 1, MaxWorkers: 3, IdleTimeout: 20 * time.Millisecond})
+	started := make(chan int, 10)
+	release := make(chan struct{})
+	// Without a queue, each task that doesn't find an idle worker starts a new one
+	var results []<-chan Result[int]
+	for i := 0; i < 3; i++ {
+		r, err := p.Submit(context.Background(), blockingTask(i, started, release))
+		if err != nil {
+			t.Fatal(err)
+		}
+		<-started
+		results = append(results, r)
+	}
+	if n := p.Workers(); n != 3 {
+		t.Errorf("Workers() = %v with 3 running tasks, want 3", n)
+	}
+
+	// A fourth task has to wait, because there are no more workers
+	submitted := make(chan struct{})
+	go func() {
+		r, _ := p.Submit(context.Background(), blockingTask(3, started, release))
+		results = append(results, r)
+		close(submitted)
+	}()
+	if waitFor(submitted, 10*time.Millisecond) {
+		t.Error("Submit() didn't block with all workers busy")
+	}
+	if n := p.Workers(); n != 3 {
+		t.Errorf("Workers() = %v, want at most 3", n)
+	}
+	close(release)
+	<-submitted
+	for _, r := range results {
+		if r := <-r; r.Err != nil {
+			t.Error(r.Err)
+		}
+	}
+	// The additional workers stop after being idle
+	waitForWorkers(t, p, 1)
+	p.Close(context.Background())
+	waitForWorkers(t, p, 0)
+}
+
+func waitFor(c <-chan struct{}, d time.Duration) bool {
+	select {
+	case <-c:
+		return true
+	case <-time.After(d):
+		return false
+	}
+}
+
+// Close waits for queued and running tasks
+func TestCloseDrain(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	p := New[int](Options{Workers: 1, QueueSize: 5})
+	var results []<-chan Result[int]
+	for i := 0; i < 5; i++ {
+		r, _ := p.Submit(context.Background(), func(ctx context.Context) (int, error) {
+			time.Sleep(time.Millisecond)
+			return i, nil
+		})
+		results = append(results, r)
+	}
+	if err := p.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range results {
+		if r := <-c; r.Err != nil || r.Value != i {
+			t.Errorf("task %v: %+v", i, r)
+		}
+	}
+	if _, err := p.Submit(context.Background(), func(ctx context.Context) (int, error) { return 0, nil }); !errors.Is(err, ErrClosed) {
+		t.Errorf("Submit() after Close = %v, want %v", err, ErrClosed)
+	}
+	// Closing twice is fine
+	if err := p.Close(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
+
+// When Close gives up, running tasks are cancelled and queued ones don't run
+func TestCloseTimeout(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	p := New[int](Options{Workers: 1, QueueSize: 5})
+	started := make(chan int, 10)
+	release := make(chan struct{}) // Never closed
+	running, _ := p.Submit(context.Background(), blockingTask(0, started, release))
+	<-started
+	var queued []<-chan Result[int]
+	for i := 1; i < 5; i++ {
+		r, _ := p.Submit(context.Background(), blockingTask(i, started, release))
+		queued = append(queued, r)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Close() = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if r := <-running; !errors.Is(r.Err, context.Canceled) {
+		t.Errorf("running task: %+v, want %v", r, context.Canceled)
+	}
+	for i, c := range queued {
+		if r := <-c; !errors.Is(r.Err, context.Canceled) {
+			t.Errorf("queued task %v: %+v, want %v", i+1, r, context.Canceled)
+		}
+	}
+	if len(started) != 0 {
+		t.Errorf("%v queued tasks started", len(started))
+	}
+}
+
+// The context of Submit is passed to the task
+func TestTaskContext(t *testing.T) {
+	defer leakcheck.Check(t)()
+
+	p := New[int](Options{Workers: 1})
+	defer p.Close(context.Background())
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan int, 1)
+	r, _ := p.Submit(ctx, blockingTask(0, started, nil))
+	<-started
+	cancel()
+	if r := <-r; !errors.Is(r.Err, context.Canceled) {
+		t.Errorf("task: %+v, want %v", r, context.Canceled)
+	}
+}